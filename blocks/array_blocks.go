@@ -0,0 +1,317 @@
+package blocks
+
+import (
+	".."
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Array blocks never change the arrays they receive, they return new ones.
+
+// The longest array Fill and Range build, beyond which they raise a VALUE_ERROR.
+const maxArrayLen = 1 << 24
+
+// Builds a NumArray from its n Num inputs IN0, IN1...
+func BuildArray(id flow.InstanceID, n int) (flow.FunctionBlock, flow.Address, *flow.Error) {
+	addr := flow.Address{"array_build", id}
+	if n < 0 {
+		return nil, addr, &flow.Error{flow.VALUE_ERROR, "n must not be negative."}
+	}
+	ins := flow.ParamTypes{}
+	for i := 0; i < n; i++ {
+		ins[fmt.Sprintf("IN%d", i)] = flow.Num
+	}
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		x := make([]float64, n)
+		for i := range x {
+			x[i] = flow.ToNum(in[fmt.Sprintf("IN%d", i)])
+		}
+		out["OUT"] = x
+	}
+	outs := flow.ParamTypes{"OUT": flow.NumArray}
+	blk, _ := opNary(addr, ins, outs, opfunc)
+	return blk, addr, nil
+}
+
+// Returns N copies of IN.
+func Fill(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) *flow.Error {
+		n := in["N"].(int)
+		if n < 0 {
+			return &flow.Error{flow.VALUE_ERROR, "N must not be negative."}
+		}
+		if n > maxArrayLen {
+			return &flow.Error{flow.VALUE_ERROR, "N is too large."}
+		}
+		x := make([]float64, n)
+		val := flow.ToNum(in["IN"])
+		for i := range x {
+			x[i] = val
+		}
+		out["OUT"] = x
+		return nil
+	}
+	addr := flow.Address{"array_fill", id}
+	ins := flow.ParamTypes{"N": flow.Int, "IN": flow.Num}
+	outs := flow.ParamTypes{"OUT": flow.NumArray}
+	return opChecked(addr, ins, outs, opfunc)
+}
+
+// Returns Start, Start+Step... up to but excluding End. Inputs which are not
+// finite, or ranges longer than maxArrayLen, raise a VALUE_ERROR.
+func Range(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) *flow.Error {
+		start, end, step := flow.ToNum(in["Start"]), flow.ToNum(in["End"]), flow.ToNum(in["Step"])
+		for _, v := range []float64{start, end, step} {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return &flow.Error{flow.VALUE_ERROR, "Start, End and Step must be finite."}
+			}
+		}
+		if step == 0 {
+			return &flow.Error{flow.VALUE_ERROR, "Step must not be zero."}
+		}
+		n := math.Max(0, math.Ceil((end-start)/step))
+		if n > maxArrayLen {
+			return &flow.Error{flow.VALUE_ERROR, "Range is too long."}
+		}
+
+		// n may be off by one from rounding, so End is still checked
+		x := make([]float64, 0, int(n)+1)
+		for i := 0; i <= int(n); i++ {
+			val := start + float64(i)*step
+			if (step > 0 && val >= end) || (step < 0 && val <= end) {
+				break
+			}
+			x = append(x, val)
+		}
+		out["OUT"] = x
+		return nil
+	}
+	addr := flow.Address{"array_range", id}
+	ins := flow.ParamTypes{"Start": flow.Num, "End": flow.Num, "Step": flow.Num}
+	outs := flow.ParamTypes{"OUT": flow.NumArray}
+	return opChecked(addr, ins, outs, opfunc)
+}
+
+func Append(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		x := in["X"].([]float64)
+		out["OUT"] = append(append(make([]float64, 0, len(x)+1), x...), flow.ToNum(in["IN"]))
+	}
+	name := "array_append"
+	addr := flow.Address{name, id}
+	return opBinary(addr, flow.NumArray, flow.Num, flow.NumArray, "X", "IN", "OUT", name, opfunc), addr
+}
+func ConcatArrays(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		a, b := in["A"].([]float64), in["B"].([]float64)
+		out["OUT"] = append(append(make([]float64, 0, len(a)+len(b)), a...), b...)
+	}
+	name := "array_concat"
+	addr := flow.Address{name, id}
+	return opBinary(addr, flow.NumArray, flow.NumArray, flow.NumArray, "A", "B", "OUT", name, opfunc), addr
+}
+
+// Returns the elements of X from Start up to but excluding End.
+func Slice(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) *flow.Error {
+		x := in["X"].([]float64)
+		start, end := in["Start"].(int), in["End"].(int)
+		if start < 0 || end < start || end > len(x) {
+			return &flow.Error{flow.VALUE_ERROR, "Slice is out of range."}
+		}
+		out["OUT"] = append([]float64{}, x[start:end]...)
+		return nil
+	}
+	addr := flow.Address{"array_slice", id}
+	ins := flow.ParamTypes{"X": flow.NumArray, "Start": flow.Int, "End": flow.Int}
+	outs := flow.ParamTypes{"OUT": flow.NumArray}
+	return opChecked(addr, ins, outs, opfunc)
+}
+
+// Ordering
+func Reverse(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		x := in["IN"].([]float64)
+		y := make([]float64, len(x))
+		for i, val := range x {
+			y[len(x)-1-i] = val
+		}
+		out["OUT"] = y
+	}
+	name := "array_reverse"
+	return opUnary(id, flow.NumArray, flow.NumArray, name, opfunc)
+}
+func Sort(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		y := append([]float64{}, in["IN"].([]float64)...)
+		sort.Float64s(y)
+		out["OUT"] = y
+	}
+	name := "array_sort"
+	return opUnary(id, flow.NumArray, flow.NumArray, name, opfunc)
+}
+func ArraySum(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		total := 0.0
+		for _, val := range in["IN"].([]float64) {
+			total += val
+		}
+		out["OUT"] = total
+	}
+	name := "array_sum"
+	return opUnary(id, flow.NumArray, flow.Float, name, opfunc)
+}
+
+// Returns the smallest element of X as OUT and the index of its first occurrence as Index.
+func ArrayMin(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opExtreme(id, "array_min", func(a, b float64) bool { return a < b })
+}
+
+// Returns the largest element of X as OUT and the index of its first occurrence as Index.
+func ArrayMax(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opExtreme(id, "array_max", func(a, b float64) bool { return a > b })
+}
+
+// Creates blocks finding the element of X which beats every other.
+func opExtreme(id flow.InstanceID, name string, beats func(a, b float64) bool) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) *flow.Error {
+		x := in["X"].([]float64)
+		if len(x) == 0 {
+			return &flow.Error{flow.VALUE_ERROR, "X is empty."}
+		}
+		best := 0
+		for i, val := range x {
+			if beats(val, x[best]) {
+				best = i
+			}
+		}
+		out["OUT"], out["Index"] = x[best], best
+		return nil
+	}
+	addr := flow.Address{name, id}
+	ins := flow.ParamTypes{"X": flow.NumArray}
+	outs := flow.ParamTypes{"OUT": flow.Float, "Index": flow.Int}
+	return opChecked(addr, ins, outs, opfunc)
+}
+
+// Returns the index of the first element of X equal to IN, or -1 if there is none.
+func Search(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		val := flow.ToNum(in["IN"])
+		out["OUT"] = -1
+		for i, el := range in["X"].([]float64) {
+			if el == val {
+				out["OUT"] = i
+				break
+			}
+		}
+	}
+	name := "array_search"
+	addr := flow.Address{name, id}
+	return opBinary(addr, flow.NumArray, flow.Num, flow.Int, "X", "IN", "OUT", name, opfunc), addr
+}
+
+// Editing
+func ReplaceAt(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opEdit(id, "array_replace", true, false, func(x []float64, i int, val float64) []float64 {
+		x[i] = val
+		return x
+	})
+}
+
+// Inserts IN before the element at Index, or at the end if Index is the length of X.
+func InsertAt(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opEdit(id, "array_insert", true, true, func(x []float64, i int, val float64) []float64 {
+		return append(x[:i], append([]float64{val}, x[i:]...)...)
+	})
+}
+func DeleteAt(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opEdit(id, "array_delete", false, false, func(x []float64, i int, val float64) []float64 {
+		return append(x[:i], x[i+1:]...)
+	})
+}
+
+// Creates blocks editing a copy of X at Index, with the value IN if with_val is true.
+// If end is true Index may also be the length of X.
+func opEdit(id flow.InstanceID, name string, with_val, end bool, edit func(x []float64, i int, val float64) []float64) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) *flow.Error {
+		x := append([]float64{}, in["X"].([]float64)...)
+		i := in["Index"].(int)
+		if i < 0 || i > len(x) || (i == len(x) && !end) {
+			return &flow.Error{flow.VALUE_ERROR, "Index is out of range."}
+		}
+		val := 0.0
+		if with_val {
+			val = flow.ToNum(in["IN"])
+		}
+		out["OUT"] = edit(x, i, val)
+		return nil
+	}
+	addr := flow.Address{name, id}
+	ins := flow.ParamTypes{"X": flow.NumArray, "Index": flow.Int}
+	if with_val {
+		ins["IN"] = flow.Num
+	}
+	outs := flow.ParamTypes{"OUT": flow.NumArray}
+	return opChecked(addr, ins, outs, opfunc)
+}
+
+// Element-wise arithmetic on A and B. An array of one element is used with every element of the other.
+func ArrayPlus(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opElementwise(id, "array_plus", func(a, b float64) float64 { return a + b })
+}
+func ArraySub(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opElementwise(id, "array_subtract", func(a, b float64) float64 { return a - b })
+}
+func ArrayMult(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opElementwise(id, "array_multiply", func(a, b float64) float64 { return a * b })
+}
+
+func opElementwise(id flow.InstanceID, name string, op func(a, b float64) float64) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) *flow.Error {
+		a, b := in["A"].([]float64), in["B"].([]float64)
+		n := len(a)
+		switch {
+		case len(a) == 1:
+			n = len(b)
+		case len(b) != 1 && len(b) != len(a):
+			return &flow.Error{flow.VALUE_ERROR, "A and B have different lengths."}
+		}
+		c := make([]float64, n)
+		for i := range c {
+			c[i] = op(a[i%len(a)], b[i%len(b)])
+		}
+		out["OUT"] = c
+		return nil
+	}
+	addr := flow.Address{name, id}
+	ins := flow.ParamTypes{"A": flow.NumArray, "B": flow.NumArray}
+	outs := flow.ParamTypes{"OUT": flow.NumArray}
+	return opChecked(addr, ins, outs, opfunc)
+}
+
+// Element-wise arithmetic of X with the scalar IN.
+func ArrayPlusNum(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opScalar(id, "array_plus_num", func(a, b float64) float64 { return a + b })
+}
+func ArraySubNum(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opScalar(id, "array_subtract_num", func(a, b float64) float64 { return a - b })
+}
+func ArrayMultNum(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opScalar(id, "array_multiply_num", func(a, b float64) float64 { return a * b })
+}
+
+func opScalar(id flow.InstanceID, name string, op func(a, b float64) float64) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		x, val := in["X"].([]float64), flow.ToNum(in["IN"])
+		y := make([]float64, len(x))
+		for i := range x {
+			y[i] = op(x[i], val)
+		}
+		out["OUT"] = y
+	}
+	addr := flow.Address{name, id}
+	return opBinary(addr, flow.NumArray, flow.Num, flow.NumArray, "X", "IN", "OUT", name, opfunc), addr
+}
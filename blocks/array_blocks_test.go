@@ -0,0 +1,264 @@
+package blocks
+
+import (
+	".."
+	"fmt"
+	"math"
+	"testing"
+)
+
+// Building arrays
+func TestBuildArray(t *testing.T) {
+	name := "array_build"
+	fmt.Println("Testing ", name, "...")
+	blk, _, _ := BuildArray(0, 3)
+	err := TestBlock(blk, flow.ParamValues{"IN0": 1, "IN1": 2.5, "IN2": 3}, flow.ParamValues{"OUT": []float64{1, 2.5, 3}})
+	if err != nil {
+		t.Error(err.Info)
+	}
+	blk, _, build_err := BuildArray(0, -1)
+	if blk != nil || build_err == nil || build_err.Class != flow.VALUE_ERROR {
+		t.Error("Negative n accepted.")
+	}
+}
+func TestFill(t *testing.T) {
+	name := "array_fill"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := Fill(0)
+	err := TestBlock(blk, flow.ParamValues{"N": 3, "IN": 2}, flow.ParamValues{"OUT": []float64{2, 2, 2}})
+	if err != nil {
+		t.Error(err.Info)
+	}
+	for _, n := range []int{-1, maxArrayLen + 1} {
+		if _, err := RunBlock(blk, flow.ParamValues{"N": n, "IN": 2}); err == nil || err.Class != flow.VALUE_ERROR {
+			t.Error("Fill accepted N of ", n)
+		}
+	}
+}
+func TestRange(t *testing.T) {
+	name := "array_range"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := Range(0)
+	err := TestBlock(blk, flow.ParamValues{"Start": 0, "End": 2, "Step": 0.5}, flow.ParamValues{"OUT": []float64{0, 0.5, 1, 1.5}})
+	if err != nil {
+		t.Error(err.Info)
+	}
+	err = TestBlock(blk, flow.ParamValues{"Start": 3, "End": 0, "Step": -1}, flow.ParamValues{"OUT": []float64{3, 2, 1}})
+	if err != nil {
+		t.Error(err.Info)
+	}
+	_, err = RunBlock(blk, flow.ParamValues{"Start": 0, "End": 1, "Step": 0})
+	if err == nil || err.Class != flow.VALUE_ERROR {
+		t.Error("Step of 0 accepted.")
+	}
+	err = TestBlock(blk, flow.ParamValues{"Start": 0, "End": 0.3, "Step": 0.1}, flow.ParamValues{"OUT": []float64{0, 0.1, 0.2}})
+	if err != nil {
+		t.Error(err.Info)
+	}
+	err = TestBlock(blk, flow.ParamValues{"Start": 0, "End": 1, "Step": -1}, flow.ParamValues{"OUT": []float64{}})
+	if err != nil {
+		t.Error(err.Info)
+	}
+	cases := []flow.ParamValues{
+		{"Start": 0, "End": math.Inf(1), "Step": 1},
+		{"Start": math.NaN(), "End": 1, "Step": 1},
+		{"Start": 0, "End": 1, "Step": math.NaN()},
+		{"Start": 0, "End": 1, "Step": 1e-300},
+		{"Start": -1e308, "End": 1e308, "Step": 1},
+	}
+	for _, in := range cases {
+		if _, err := RunBlock(blk, in); err == nil || err.Class != flow.VALUE_ERROR {
+			t.Error("Range accepted ", in)
+		}
+	}
+}
+func TestAppend(t *testing.T) {
+	name := "array_append"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := Append(0)
+	x := []float64{1, 2}
+	err := TestBlock(blk, flow.ParamValues{"X": x, "IN": 3}, flow.ParamValues{"OUT": []float64{1, 2, 3}})
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
+func TestConcatArrays(t *testing.T) {
+	name := "array_concat"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := ConcatArrays(0)
+	err := TestBlock(blk, flow.ParamValues{"A": []float64{1}, "B": []float64{2, 3}}, flow.ParamValues{"OUT": []float64{1, 2, 3}})
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
+func TestSlice(t *testing.T) {
+	name := "array_slice"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := Slice(0)
+	x := []float64{1, 2, 3, 4}
+	err := TestBlock(blk, flow.ParamValues{"X": x, "Start": 1, "End": 3}, flow.ParamValues{"OUT": []float64{2, 3}})
+	if err != nil {
+		t.Error(err.Info)
+	}
+	_, err = RunBlock(blk, flow.ParamValues{"X": x, "Start": 2, "End": 5})
+	if err == nil || err.Class != flow.VALUE_ERROR {
+		t.Error("Out of range slice accepted.")
+	}
+}
+
+// Ordering
+func TestReverse(t *testing.T) {
+	name := "array_reverse"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := Reverse(0)
+	err := TestBlock(blk, flow.ParamValues{"IN": []float64{1, 2, 3}}, flow.ParamValues{"OUT": []float64{3, 2, 1}})
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
+func TestSort(t *testing.T) {
+	name := "array_sort"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := Sort(0)
+	x := []float64{3, 1, 2}
+	err := TestBlock(blk, flow.ParamValues{"IN": x}, flow.ParamValues{"OUT": []float64{1, 2, 3}})
+	if err != nil {
+		t.Error(err.Info)
+	}
+	if x[0] != 3 {
+		t.Error("Input was sorted in place.")
+	}
+}
+func TestArraySum(t *testing.T) {
+	name := "array_sum"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := ArraySum(0)
+	err := TestUnary(blk, []float64{1, 2, 3}, 6.0, "IN", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
+func TestArrayMinMax(t *testing.T) {
+	name := "array_min"
+	fmt.Println("Testing ", name, "...")
+	x := []float64{3, 1, 4, 1, 5}
+	blk, _ := ArrayMin(0)
+	err := TestBlock(blk, flow.ParamValues{"X": x}, flow.ParamValues{"OUT": 1.0, "Index": 1})
+	if err != nil {
+		t.Error(err.Info)
+	}
+	blk, _ = ArrayMax(0)
+	err = TestBlock(blk, flow.ParamValues{"X": x}, flow.ParamValues{"OUT": 5.0, "Index": 4})
+	if err != nil {
+		t.Error(err.Info)
+	}
+	_, err = RunBlock(blk, flow.ParamValues{"X": []float64{}})
+	if err == nil || err.Class != flow.VALUE_ERROR {
+		t.Error("Empty array accepted.")
+	}
+}
+func TestSearch(t *testing.T) {
+	name := "array_search"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := Search(0)
+	err := TestBinary(blk, []float64{1, 2, 3}, 2, 1, "X", "IN", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+	err = TestBinary(blk, []float64{1, 2, 3}, 7.5, -1, "X", "IN", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
+
+// Editing
+func TestReplaceAt(t *testing.T) {
+	name := "array_replace"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := ReplaceAt(0)
+	x := []float64{1, 2, 3}
+	err := TestBlock(blk, flow.ParamValues{"X": x, "Index": 1, "IN": 9}, flow.ParamValues{"OUT": []float64{1, 9, 3}})
+	if err != nil {
+		t.Error(err.Info)
+	}
+	if x[1] != 2 {
+		t.Error("Input was changed.")
+	}
+	_, err = RunBlock(blk, flow.ParamValues{"X": x, "Index": 3, "IN": 9})
+	if err == nil || err.Class != flow.VALUE_ERROR {
+		t.Error("Out of range index accepted.")
+	}
+}
+func TestInsertAt(t *testing.T) {
+	name := "array_insert"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := InsertAt(0)
+	x := []float64{1, 2}
+	err := TestBlock(blk, flow.ParamValues{"X": x, "Index": 1, "IN": 9}, flow.ParamValues{"OUT": []float64{1, 9, 2}})
+	if err != nil {
+		t.Error(err.Info)
+	}
+	err = TestBlock(blk, flow.ParamValues{"X": x, "Index": 2, "IN": 9}, flow.ParamValues{"OUT": []float64{1, 2, 9}})
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
+func TestDeleteAt(t *testing.T) {
+	name := "array_delete"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := DeleteAt(0)
+	x := []float64{1, 2, 3}
+	err := TestBlock(blk, flow.ParamValues{"X": x, "Index": 0}, flow.ParamValues{"OUT": []float64{2, 3}})
+	if err != nil {
+		t.Error(err.Info)
+	}
+	if x[0] != 1 {
+		t.Error("Input was changed.")
+	}
+}
+
+// Arithmetic
+func TestArrayArithmetic(t *testing.T) {
+	name := "array_plus"
+	fmt.Println("Testing ", name, "...")
+	a, b := []float64{1, 2, 3}, []float64{4, 5, 6}
+	blk, _ := ArrayPlus(0)
+	err := TestBlock(blk, flow.ParamValues{"A": a, "B": b}, flow.ParamValues{"OUT": []float64{5, 7, 9}})
+	if err != nil {
+		t.Error(err.Info)
+	}
+	blk, _ = ArraySub(0)
+	err = TestBlock(blk, flow.ParamValues{"A": []float64{10}, "B": b}, flow.ParamValues{"OUT": []float64{6, 5, 4}})
+	if err != nil {
+		t.Error(err.Info)
+	}
+	blk, _ = ArrayMult(0)
+	err = TestBlock(blk, flow.ParamValues{"A": a, "B": []float64{2}}, flow.ParamValues{"OUT": []float64{2, 4, 6}})
+	if err != nil {
+		t.Error(err.Info)
+	}
+	_, err = RunBlock(blk, flow.ParamValues{"A": a, "B": []float64{1, 2}})
+	if err == nil || err.Class != flow.VALUE_ERROR {
+		t.Error("Arrays of different lengths accepted.")
+	}
+}
+func TestArrayScalar(t *testing.T) {
+	name := "array_plus_num"
+	fmt.Println("Testing ", name, "...")
+	x := []float64{1, 2, 3}
+	blk, _ := ArrayPlusNum(0)
+	err := TestBlock(blk, flow.ParamValues{"X": x, "IN": 1}, flow.ParamValues{"OUT": []float64{2, 3, 4}})
+	if err != nil {
+		t.Error(err.Info)
+	}
+	blk, _ = ArraySubNum(0)
+	err = TestBlock(blk, flow.ParamValues{"X": x, "IN": 0.5}, flow.ParamValues{"OUT": []float64{0.5, 1.5, 2.5}})
+	if err != nil {
+		t.Error(err.Info)
+	}
+	blk, _ = ArrayMultNum(0)
+	err = TestBlock(blk, flow.ParamValues{"X": x, "IN": -1}, flow.ParamValues{"OUT": []float64{-1, -2, -3}})
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
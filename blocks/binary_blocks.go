@@ -20,8 +20,44 @@ func opBinary(addr flow.Address, aT, bT, cT flow.Type, aN, bN, cN string,
 		return
 	}
 
-	// Initialize the block and return
-	return flow.NewPrimitive(addr.Name, runfunc, ins, outs)
+	// Initialize the block and return, its outputs depend only on its inputs
+	return flow.Pure(flow.NewPrimitive(addr.Name, runfunc, ins, outs))
+}
+
+// Creates blocks with any parameters whose operation cannot fail.
+func opNary(addr flow.Address, ins, outs flow.ParamTypes,
+	opfunc func(in flow.ParamValues, out flow.ParamValues)) (flow.FunctionBlock, flow.Address) {
+	runfunc := func(inputs flow.ParamValues,
+		outputs chan flow.ParamValues,
+		stop chan bool,
+		err chan *flow.Error) {
+		data := make(flow.ParamValues)
+		opfunc(inputs, data)
+		outputs <- data
+	}
+
+	// Initialize the block and return, its outputs depend only on its inputs
+	return flow.Pure(flow.NewPrimitive(addr.Name, runfunc, ins, outs)), addr
+}
+
+// Creates blocks with any parameters whose operation may fail, like parsing or checked math.
+// An error returned by opfunc is raised instead of passing outputs.
+func opChecked(addr flow.Address, ins, outs flow.ParamTypes,
+	opfunc func(in flow.ParamValues, out flow.ParamValues) *flow.Error) (flow.FunctionBlock, flow.Address) {
+	runfunc := func(inputs flow.ParamValues,
+		outputs chan flow.ParamValues,
+		stop chan bool,
+		err chan *flow.Error) {
+		data := make(flow.ParamValues)
+		if op_err := opfunc(inputs, data); op_err != nil {
+			err <- op_err
+			return
+		}
+		outputs <- data
+	}
+
+	// Initialize the block and return, its outputs depend only on its inputs
+	return flow.Pure(flow.NewPrimitive(addr.Name, runfunc, ins, outs)), addr
 }
 
 // Numeric Float Functions
@@ -0,0 +1,155 @@
+package blocks
+
+import (
+	".."
+	"math"
+	"strconv"
+)
+
+// Conversions between numbers, strings and booleans. Strings which cannot be
+// parsed, and numbers which cannot be represented, raise a VALUE_ERROR.
+
+// Formats the Num IN with Precision digits after the point, or as few as
+// needed to read it back exactly if Precision is -1.
+func NumtoString(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) *flow.Error {
+		prec := in["Precision"].(int)
+		if prec < -1 {
+			return &flow.Error{flow.VALUE_ERROR, "Precision must be -1 or more."}
+		}
+		out["OUT"] = strconv.FormatFloat(flow.ToNum(in["IN"]), 'f', prec, 64)
+		return nil
+	}
+	addr := flow.Address{"num_to_string", id}
+	ins := flow.ParamTypes{"IN": flow.Num, "Precision": flow.Int}
+	outs := flow.ParamTypes{"OUT": flow.String}
+	return opChecked(addr, ins, outs, opfunc)
+}
+
+// Formats the Int IN in Base, between 2 and 36.
+func InttoString(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) *flow.Error {
+		base := in["Base"].(int)
+		if base < 2 || base > 36 {
+			return &flow.Error{flow.VALUE_ERROR, "Base must be between 2 and 36."}
+		}
+		out["OUT"] = strconv.FormatInt(int64(in["IN"].(int)), base)
+		return nil
+	}
+	addr := flow.Address{"int_to_string", id}
+	ins := flow.ParamTypes{"IN": flow.Int, "Base": flow.Int}
+	outs := flow.ParamTypes{"OUT": flow.String}
+	return opChecked(addr, ins, outs, opfunc)
+}
+
+// Parses a Float from IN.
+func StringtoFloat(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) *flow.Error {
+		x, err := strconv.ParseFloat(in["IN"].(string), 64)
+		if err != nil {
+			return &flow.Error{flow.VALUE_ERROR, "Cannot parse IN: " + err.Error()}
+		}
+		out["OUT"] = x
+		return nil
+	}
+	addr := flow.Address{"string_to_float", id}
+	ins := flow.ParamTypes{"IN": flow.String}
+	outs := flow.ParamTypes{"OUT": flow.Float}
+	return opChecked(addr, ins, outs, opfunc)
+}
+
+// Parses an Int in Base from IN. A Base of 0 is taken from the prefix of IN,
+// as in 0x1f or 0b101, and is 10 without one.
+func StringtoInt(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) *flow.Error {
+		base := in["Base"].(int)
+		if base != 0 && (base < 2 || base > 36) {
+			return &flow.Error{flow.VALUE_ERROR, "Base must be 0 or between 2 and 36."}
+		}
+		x, err := strconv.ParseInt(in["IN"].(string), base, 0)
+		if err != nil {
+			return &flow.Error{flow.VALUE_ERROR, "Cannot parse IN: " + err.Error()}
+		}
+		out["OUT"] = int(x)
+		return nil
+	}
+	addr := flow.Address{"string_to_int", id}
+	ins := flow.ParamTypes{"IN": flow.String, "Base": flow.Int}
+	outs := flow.ParamTypes{"OUT": flow.Int}
+	return opChecked(addr, ins, outs, opfunc)
+}
+
+// Booleans
+func BooltoString(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		out["OUT"] = strconv.FormatBool(in["IN"].(bool))
+	}
+	name := "bool_to_string"
+	return opUnary(id, flow.Bool, flow.String, name, opfunc)
+}
+
+// Parses a Bool from IN, accepting 1, t, T, TRUE, true, True and their negations.
+func StringtoBool(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) *flow.Error {
+		b, err := strconv.ParseBool(in["IN"].(string))
+		if err != nil {
+			return &flow.Error{flow.VALUE_ERROR, "Cannot parse IN: " + err.Error()}
+		}
+		out["OUT"] = b
+		return nil
+	}
+	addr := flow.Address{"string_to_bool", id}
+	ins := flow.ParamTypes{"IN": flow.String}
+	outs := flow.ParamTypes{"OUT": flow.Bool}
+	return opChecked(addr, ins, outs, opfunc)
+}
+func BooltoInt(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		if in["IN"].(bool) {
+			out["OUT"] = 1
+		} else {
+			out["OUT"] = 0
+		}
+	}
+	name := "bool_to_int"
+	return opUnary(id, flow.Bool, flow.Int, name, opfunc)
+}
+
+// Returns true for any Int but 0.
+func InttoBool(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		out["OUT"] = in["IN"].(int) != 0
+	}
+	name := "int_to_bool"
+	return opUnary(id, flow.Int, flow.Bool, name, opfunc)
+}
+
+// Creates blocks rounding the Num IN to an Int with f.
+func opToInt(id flow.InstanceID, name string, f func(x float64) float64) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) *flow.Error {
+		x := f(flow.ToNum(in["IN"]))
+		if math.IsNaN(x) || x < math.MinInt64 || x >= math.MaxInt64 || int64(int(x)) != int64(x) {
+			return &flow.Error{flow.VALUE_ERROR, "IN is out of the range of Int."}
+		}
+		out["OUT"] = int(x)
+		return nil
+	}
+	addr := flow.Address{name, id}
+	ins := flow.ParamTypes{"IN": flow.Num}
+	outs := flow.ParamTypes{"OUT": flow.Int}
+	return opChecked(addr, ins, outs, opfunc)
+}
+
+// Rounding to Int. Round rounds half away from zero.
+func RoundtoInt(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opToInt(id, "round_to_int", math.Round)
+}
+func FloortoInt(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opToInt(id, "floor_to_int", math.Floor)
+}
+func CeiltoInt(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opToInt(id, "ceil_to_int", math.Ceil)
+}
+func TrunctoInt(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opToInt(id, "trunc_to_int", math.Trunc)
+}
@@ -0,0 +1,133 @@
+package blocks
+
+import (
+	".."
+	"fmt"
+	"math"
+	"testing"
+)
+
+// Numbers to strings
+func TestNumtoString(t *testing.T) {
+	name := "num_to_string"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := NumtoString(0)
+	for prec, c := range map[int]string{-1: "3.14159", 0: "3", 2: "3.14"} {
+		err := TestBinary(blk, 3.14159, prec, c, "IN", "Precision", "OUT", name)
+		if err != nil {
+			t.Error(prec, ": ", err.Info)
+		}
+	}
+	_, err := RunBlock(blk, flow.ParamValues{"IN": 1, "Precision": -2})
+	if err == nil || err.Class != flow.VALUE_ERROR {
+		t.Error("Precision of -2 accepted.")
+	}
+}
+func TestInttoString(t *testing.T) {
+	name := "int_to_string"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := InttoString(0)
+	for base, c := range map[int]string{2: "-101", 10: "-5", 16: "-5"} {
+		err := TestBinary(blk, -5, base, c, "IN", "Base", "OUT", name)
+		if err != nil {
+			t.Error(base, ": ", err.Info)
+		}
+	}
+	_, err := RunBlock(blk, flow.ParamValues{"IN": 1, "Base": 1})
+	if err == nil || err.Class != flow.VALUE_ERROR {
+		t.Error("Base of 1 accepted.")
+	}
+}
+
+// Strings to numbers
+func TestStringtoFloat(t *testing.T) {
+	name := "string_to_float"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := StringtoFloat(0)
+	err := TestUnary(blk, "-2.5e1", -25.0, "IN", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+	_, err = RunBlock(blk, flow.ParamValues{"IN": "two"})
+	if err == nil || err.Class != flow.VALUE_ERROR {
+		t.Error("Invalid float parsed.")
+	}
+}
+func TestStringtoInt(t *testing.T) {
+	name := "string_to_int"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := StringtoInt(0)
+	cases := []struct {
+		in   string
+		base int
+		c    int
+	}{
+		{"42", 10, 42}, {"-ff", 16, -255}, {"0x1f", 0, 31}, {"101", 2, 5},
+	}
+	for _, cs := range cases {
+		err := TestBinary(blk, cs.in, cs.base, cs.c, "IN", "Base", "OUT", name)
+		if err != nil {
+			t.Error(cs.in, ": ", err.Info)
+		}
+	}
+	for _, in := range []flow.ParamValues{{"IN": "12", "Base": 2}, {"IN": "1.5", "Base": 10}, {"IN": "1", "Base": 40}} {
+		if _, err := RunBlock(blk, in); err == nil || err.Class != flow.VALUE_ERROR {
+			t.Error("StringtoInt accepted ", in)
+		}
+	}
+}
+
+// Booleans
+func TestBoolConversions(t *testing.T) {
+	name := "bool_to_string"
+	fmt.Println("Testing ", name, "...")
+	tostr, _ := BooltoString(0)
+	toint, _ := BooltoInt(0)
+	for b, c := range map[bool]string{true: "true", false: "false"} {
+		if err := TestUnary(tostr, b, c, "IN", "OUT", name); err != nil {
+			t.Error(err.Info)
+		}
+	}
+	if err := TestUnary(toint, true, 1, "IN", "OUT", name); err != nil {
+		t.Error(err.Info)
+	}
+	frint, _ := InttoBool(0)
+	for i, c := range map[int]bool{0: false, 1: true, -3: true} {
+		if err := TestUnary(frint, i, c, "IN", "OUT", name); err != nil {
+			t.Error(i, ": ", err.Info)
+		}
+	}
+	frstr, _ := StringtoBool(0)
+	if err := TestUnary(frstr, "T", true, "IN", "OUT", name); err != nil {
+		t.Error(err.Info)
+	}
+	if _, err := RunBlock(frstr, flow.ParamValues{"IN": "yes"}); err == nil || err.Class != flow.VALUE_ERROR {
+		t.Error("Invalid bool parsed.")
+	}
+}
+
+// Rounding to Int
+func TestRoundingtoInt(t *testing.T) {
+	cases := []struct {
+		blk mathBlock
+		in  interface{}
+		c   int
+	}{
+		{RoundtoInt, 2.5, 3}, {RoundtoInt, -2.5, -3}, {FloortoInt, -1.5, -2}, {CeiltoInt, 1.2, 2},
+		{TrunctoInt, -1.7, -1}, {TrunctoInt, 4, 4},
+	}
+	for _, cs := range cases {
+		blk, addr := cs.blk(0)
+		fmt.Println("Testing ", addr.Name, "...")
+		err := TestUnary(blk, cs.in, cs.c, "IN", "OUT", addr.Name)
+		if err != nil {
+			t.Error(addr.Name, ": ", err.Info)
+		}
+	}
+	blk, _ := RoundtoInt(0)
+	for _, in := range []float64{math.NaN(), math.Inf(1), 1e300} {
+		if _, err := RunBlock(blk, flow.ParamValues{"IN": in}); err == nil || err.Class != flow.VALUE_ERROR {
+			t.Error("RoundtoInt accepted ", in)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package blocks
+
+import (
+	".."
+	"fmt"
+	"math"
+)
+
+// Numeric comparison
+func GreaterEq(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		out["OUT"] = flow.ToNum(in["A"]) >= flow.ToNum(in["B"])
+	}
+	name := "greater_or_equal"
+	addr := flow.Address{name, id}
+	return opBinary(addr, flow.Num, flow.Num, flow.Bool, "A", "B", "OUT", name, opfunc), addr
+}
+func LesserEq(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		out["OUT"] = flow.ToNum(in["A"]) <= flow.ToNum(in["B"])
+	}
+	name := "lesser_or_equal"
+	addr := flow.Address{name, id}
+	return opBinary(addr, flow.Num, flow.Num, flow.Bool, "A", "B", "OUT", name, opfunc), addr
+}
+func NotEquals(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		out["OUT"] = flow.ToNum(in["A"]) != flow.ToNum(in["B"])
+	}
+	name := "not_equals"
+	addr := flow.Address{name, id}
+	return opBinary(addr, flow.Num, flow.Num, flow.Bool, "A", "B", "OUT", name, opfunc), addr
+}
+
+// Returns true if Min <= IN <= Max.
+func InRange(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		x, lo, hi := flow.ToNum(in["IN"]), flow.ToNum(in["Min"]), flow.ToNum(in["Max"])
+		out["OUT"] = lo <= x && x <= hi
+	}
+	addr := flow.Address{"in_range", id}
+	ins := flow.ParamTypes{"IN": flow.Num, "Min": flow.Num, "Max": flow.Num}
+	outs := flow.ParamTypes{"OUT": flow.Bool}
+	return opNary(addr, ins, outs, opfunc)
+}
+
+// Returns true if A and B differ by at most Tolerance.
+func ApproxEquals(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) *flow.Error {
+		tol := flow.ToNum(in["Tolerance"])
+		if tol < 0 {
+			return &flow.Error{flow.VALUE_ERROR, "Tolerance must not be negative."}
+		}
+		out["OUT"] = math.Abs(flow.ToNum(in["A"])-flow.ToNum(in["B"])) <= tol
+		return nil
+	}
+	addr := flow.Address{"approx_equals", id}
+	ins := flow.ParamTypes{"A": flow.Num, "B": flow.Num, "Tolerance": flow.Num}
+	outs := flow.ParamTypes{"OUT": flow.Bool}
+	return opChecked(addr, ins, outs, opfunc)
+}
+
+// String comparison, in byte order
+func StrEquals(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		out["OUT"] = in["A"].(string) == in["B"].(string)
+	}
+	name := "string_equals"
+	addr := flow.Address{name, id}
+	return opBinary(addr, flow.String, flow.String, flow.Bool, "A", "B", "OUT", name, opfunc), addr
+}
+func StrLesser(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		out["OUT"] = in["A"].(string) < in["B"].(string)
+	}
+	name := "string_lesser_than"
+	addr := flow.Address{name, id}
+	return opBinary(addr, flow.String, flow.String, flow.Bool, "A", "B", "OUT", name, opfunc), addr
+}
+func StrGreater(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		out["OUT"] = in["A"].(string) > in["B"].(string)
+	}
+	name := "string_greater_than"
+	addr := flow.Address{name, id}
+	return opBinary(addr, flow.String, flow.String, flow.Bool, "A", "B", "OUT", name, opfunc), addr
+}
+
+// Boolean comparison
+func BoolEquals(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		out["OUT"] = in["A"].(bool) == in["B"].(bool)
+	}
+	name := "bool_equals"
+	addr := flow.Address{name, id}
+	return opBinary(addr, flow.Bool, flow.Bool, flow.Bool, "A", "B", "OUT", name, opfunc), addr
+}
+func Nand(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		out["OUT"] = !(in["A"].(bool) && in["B"].(bool))
+	}
+	name := "logical_nand"
+	addr := flow.Address{name, id}
+	return opBinary(addr, flow.Bool, flow.Bool, flow.Bool, "A", "B", "OUT", name, opfunc), addr
+}
+func Nor(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		out["OUT"] = !(in["A"].(bool) || in["B"].(bool))
+	}
+	name := "logical_nor"
+	addr := flow.Address{name, id}
+	return opBinary(addr, flow.Bool, flow.Bool, flow.Bool, "A", "B", "OUT", name, opfunc), addr
+}
+
+// Returns A if Condition is true, B otherwise. Unlike InputSwitch it is pure, so it can be cached.
+func Ternary(id flow.InstanceID, t flow.Type) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		if in["Condition"].(bool) {
+			out["OUT"] = in["A"]
+		} else {
+			out["OUT"] = in["B"]
+		}
+	}
+	addr := flow.Address{"ternary", id}
+	ins := flow.ParamTypes{"A": t, "B": t, "Condition": flow.Bool}
+	outs := flow.ParamTypes{"OUT": t}
+	return opNary(addr, ins, outs, opfunc)
+}
+
+// Builds a BoolArray from its n Bool inputs IN0, IN1...
+func BuildBoolArray(id flow.InstanceID, n int) (flow.FunctionBlock, flow.Address, *flow.Error) {
+	addr := flow.Address{"bool_array_build", id}
+	if n < 0 {
+		return nil, addr, &flow.Error{flow.VALUE_ERROR, "n must not be negative."}
+	}
+	ins := flow.ParamTypes{}
+	for i := 0; i < n; i++ {
+		ins[fmt.Sprintf("IN%d", i)] = flow.Bool
+	}
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		x := make([]bool, n)
+		for i := range x {
+			x[i] = in[fmt.Sprintf("IN%d", i)].(bool)
+		}
+		out["OUT"] = x
+	}
+	outs := flow.ParamTypes{"OUT": flow.BoolArray}
+	blk, _ := opNary(addr, ins, outs, opfunc)
+	return blk, addr, nil
+}
+
+// Returns true if every element of the BoolArray IN is true, or if it is empty.
+func AndArray(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		all := true
+		for _, b := range in["IN"].([]bool) {
+			all = all && b
+		}
+		out["OUT"] = all
+	}
+	name := "logical_and_array"
+	return opUnary(id, flow.BoolArray, flow.Bool, name, opfunc)
+}
+
+// Returns true if any element of the BoolArray IN is true.
+func OrArray(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		any := false
+		for _, b := range in["IN"].([]bool) {
+			any = any || b
+		}
+		out["OUT"] = any
+	}
+	name := "logical_or_array"
+	return opUnary(id, flow.BoolArray, flow.Bool, name, opfunc)
+}
@@ -0,0 +1,170 @@
+package blocks
+
+import (
+	".."
+	"fmt"
+	"testing"
+)
+
+// Numeric comparison
+func TestGreaterEq(t *testing.T) {
+	name := "greater_or_equal"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := GreaterEq(0)
+	for _, b := range []interface{}{1, 2.0} {
+		if err := TestBinary(blk, 2, b, true, "A", "B", "OUT", name); err != nil {
+			t.Error(err.Info)
+		}
+	}
+	if err := TestBinary(blk, 2, 2.5, false, "A", "B", "OUT", name); err != nil {
+		t.Error(err.Info)
+	}
+}
+func TestLesserEq(t *testing.T) {
+	name := "lesser_or_equal"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := LesserEq(0)
+	if err := TestBinary(blk, 2, 2.0, true, "A", "B", "OUT", name); err != nil {
+		t.Error(err.Info)
+	}
+	if err := TestBinary(blk, 3, 2, false, "A", "B", "OUT", name); err != nil {
+		t.Error(err.Info)
+	}
+}
+func TestNotEquals(t *testing.T) {
+	name := "not_equals"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := NotEquals(0)
+	if err := TestBinary(blk, 2, 2.0, false, "A", "B", "OUT", name); err != nil {
+		t.Error(err.Info)
+	}
+	if err := TestBinary(blk, 2, 3, true, "A", "B", "OUT", name); err != nil {
+		t.Error(err.Info)
+	}
+}
+func TestInRange(t *testing.T) {
+	name := "in_range"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := InRange(0)
+	for in, c := range map[float64]bool{0: true, 0.5: true, 1: true, 1.5: false, -1: false} {
+		err := TestBlock(blk, flow.ParamValues{"IN": in, "Min": 0, "Max": 1}, flow.ParamValues{"OUT": c})
+		if err != nil {
+			t.Error(in, ": ", err.Info)
+		}
+	}
+}
+func TestApproxEquals(t *testing.T) {
+	name := "approx_equals"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := ApproxEquals(0)
+	err := TestBlock(blk, flow.ParamValues{"A": 0.1 + 0.2, "B": 0.3, "Tolerance": 1e-9}, flow.ParamValues{"OUT": true})
+	if err != nil {
+		t.Error(err.Info)
+	}
+	err = TestBlock(blk, flow.ParamValues{"A": 1, "B": 1.1, "Tolerance": 0.01}, flow.ParamValues{"OUT": false})
+	if err != nil {
+		t.Error(err.Info)
+	}
+	_, err = RunBlock(blk, flow.ParamValues{"A": 1, "B": 1, "Tolerance": -1})
+	if err == nil || err.Class != flow.VALUE_ERROR {
+		t.Error("Negative tolerance accepted.")
+	}
+}
+
+// String and boolean comparison
+func TestStrCompare(t *testing.T) {
+	name := "string_equals"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := StrEquals(0)
+	if err := TestBinary(blk, "flow", "flow", true, "A", "B", "OUT", name); err != nil {
+		t.Error(err.Info)
+	}
+	blk, _ = StrLesser(0)
+	if err := TestBinary(blk, "apple", "banana", true, "A", "B", "OUT", name); err != nil {
+		t.Error(err.Info)
+	}
+	blk, _ = StrGreater(0)
+	if err := TestBinary(blk, "apple", "banana", false, "A", "B", "OUT", name); err != nil {
+		t.Error(err.Info)
+	}
+}
+func TestBoolCompare(t *testing.T) {
+	name := "bool_equals"
+	fmt.Println("Testing ", name, "...")
+	cases := []struct {
+		a, b              bool
+		equals, nand, nor bool
+	}{
+		{true, true, true, false, false},
+		{true, false, false, true, false},
+		{false, false, true, true, true},
+	}
+	eq, _ := BoolEquals(0)
+	nand, _ := Nand(0)
+	nor, _ := Nor(0)
+	for _, cs := range cases {
+		if err := TestBinary(eq, cs.a, cs.b, cs.equals, "A", "B", "OUT", name); err != nil {
+			t.Error("bool_equals: ", err.Info)
+		}
+		if err := TestBinary(nand, cs.a, cs.b, cs.nand, "A", "B", "OUT", name); err != nil {
+			t.Error("logical_nand: ", err.Info)
+		}
+		if err := TestBinary(nor, cs.a, cs.b, cs.nor, "A", "B", "OUT", name); err != nil {
+			t.Error("logical_nor: ", err.Info)
+		}
+	}
+}
+
+// Selection
+func TestTernary(t *testing.T) {
+	name := "ternary"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := Ternary(0, flow.String)
+	for cnd, c := range map[bool]string{true: "yes", false: "no"} {
+		err := TestBlock(blk, flow.ParamValues{"A": "yes", "B": "no", "Condition": cnd}, flow.ParamValues{"OUT": c})
+		if err != nil {
+			t.Error(err.Info)
+		}
+	}
+	if !flow.IsPure(blk) {
+		t.Error("Ternary is not pure.")
+	}
+}
+
+// N-ary logic
+func TestBuildBoolArray(t *testing.T) {
+	name := "bool_array_build"
+	fmt.Println("Testing ", name, "...")
+	blk, _, _ := BuildBoolArray(0, 2)
+	err := TestBlock(blk, flow.ParamValues{"IN0": true, "IN1": false}, flow.ParamValues{"OUT": []bool{true, false}})
+	if err != nil {
+		t.Error(err.Info)
+	}
+	blk, _, build_err := BuildBoolArray(0, -1)
+	if blk != nil || build_err == nil || build_err.Class != flow.VALUE_ERROR {
+		t.Error("Negative n accepted.")
+	}
+}
+func TestAndOrArray(t *testing.T) {
+	name := "logical_and_array"
+	fmt.Println("Testing ", name, "...")
+	and, _ := AndArray(0)
+	or, _ := OrArray(0)
+	cases := []struct {
+		in      []bool
+		and, or bool
+	}{
+		{[]bool{true, true, true}, true, true},
+		{[]bool{true, false, true}, false, true},
+		{[]bool{false, false}, false, false},
+		{[]bool{}, true, false},
+	}
+	for _, cs := range cases {
+		if err := TestUnary(and, cs.in, cs.and, "IN", "OUT", name); err != nil {
+			t.Error(cs.in, ": ", err.Info)
+		}
+		if err := TestUnary(or, cs.in, cs.or, "IN", "OUT", name); err != nil {
+			t.Error(cs.in, ": ", err.Info)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package blocks
+
+import (
+	".."
+	"math"
+)
+
+// Math blocks take Num inputs and return a Float OUT. Inputs outside the domain
+// of a function, and results which are not finite, raise a VALUE_ERROR.
+
+// Creates blocks applying f to IN, for which valid must hold.
+func opMath(id flow.InstanceID, name string, valid func(x float64) bool, f func(x float64) float64) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) *flow.Error {
+		x := flow.ToNum(in["IN"])
+		if valid != nil && !valid(x) {
+			return &flow.Error{flow.VALUE_ERROR, "IN is outside the domain of " + name + "."}
+		}
+		return finite(out, f(x))
+	}
+	addr := flow.Address{name, id}
+	ins := flow.ParamTypes{"IN": flow.Num}
+	outs := flow.ParamTypes{"OUT": flow.Float}
+	return opChecked(addr, ins, outs, opfunc)
+}
+
+// Sets OUT to x, unless it is not finite.
+func finite(out flow.ParamValues, x float64) *flow.Error {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return &flow.Error{flow.VALUE_ERROR, "Result is not a finite number."}
+	}
+	out["OUT"] = x
+	return nil
+}
+
+func nonNegative(x float64) bool { return x >= 0 }
+func positive(x float64) bool    { return x > 0 }
+func unit(x float64) bool        { return x >= -1 && x <= 1 }
+
+// Powers and logarithms
+func Sqrt(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opMath(id, "math_sqrt", nonNegative, math.Sqrt)
+}
+func Exp(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opMath(id, "math_exp", nil, math.Exp)
+}
+func Log(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opMath(id, "math_log", positive, math.Log)
+}
+func Log10(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opMath(id, "math_log10", positive, math.Log10)
+}
+func Log2(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opMath(id, "math_log2", positive, math.Log2)
+}
+
+// Returns A to the power of B.
+func Pow(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) *flow.Error {
+		a, b := flow.ToNum(in["A"]), flow.ToNum(in["B"])
+		switch {
+		case a < 0 && b != math.Trunc(b):
+			return &flow.Error{flow.VALUE_ERROR, "Negative A has no power of a fractional B."}
+		case a == 0 && b < 0:
+			return &flow.Error{flow.VALUE_ERROR, "Zero A has no power of a negative B."}
+		}
+		return finite(out, math.Pow(a, b))
+	}
+	addr := flow.Address{"math_pow", id}
+	ins := flow.ParamTypes{"A": flow.Num, "B": flow.Num}
+	outs := flow.ParamTypes{"OUT": flow.Float}
+	return opChecked(addr, ins, outs, opfunc)
+}
+
+// Rounding
+func Abs(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opMath(id, "math_abs", nil, math.Abs)
+}
+func Floor(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opMath(id, "math_floor", nil, math.Floor)
+}
+func Ceil(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opMath(id, "math_ceil", nil, math.Ceil)
+}
+
+// Rounds half away from zero.
+func Round(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opMath(id, "math_round", nil, math.Round)
+}
+
+// Trigonometry, in radians
+func Sin(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opMath(id, "math_sin", nil, math.Sin)
+}
+func Cos(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opMath(id, "math_cos", nil, math.Cos)
+}
+func Tan(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opMath(id, "math_tan", nil, math.Tan)
+}
+func Asin(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opMath(id, "math_asin", unit, math.Asin)
+}
+func Acos(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opMath(id, "math_acos", unit, math.Acos)
+}
+func Atan(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opMath(id, "math_atan", nil, math.Atan)
+}
+
+// Returns the angle of the point (X, Y).
+func Atan2(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) *flow.Error {
+		return finite(out, math.Atan2(flow.ToNum(in["Y"]), flow.ToNum(in["X"])))
+	}
+	addr := flow.Address{"math_atan2", id}
+	ins := flow.ParamTypes{"Y": flow.Num, "X": flow.Num}
+	outs := flow.ParamTypes{"OUT": flow.Float}
+	return opChecked(addr, ins, outs, opfunc)
+}
+
+// Hyperbolic functions
+func Sinh(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opMath(id, "math_sinh", nil, math.Sinh)
+}
+func Cosh(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opMath(id, "math_cosh", nil, math.Cosh)
+}
+func Tanh(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opMath(id, "math_tanh", nil, math.Tanh)
+}
+func Asinh(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opMath(id, "math_asinh", nil, math.Asinh)
+}
+func Acosh(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opMath(id, "math_acosh", func(x float64) bool { return x >= 1 }, math.Acosh)
+}
+func Atanh(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opMath(id, "math_atanh", func(x float64) bool { return x > -1 && x < 1 }, math.Atanh)
+}
+
+// Limits
+func Min(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) *flow.Error {
+		return finite(out, math.Min(flow.ToNum(in["A"]), flow.ToNum(in["B"])))
+	}
+	addr := flow.Address{"math_min", id}
+	ins := flow.ParamTypes{"A": flow.Num, "B": flow.Num}
+	outs := flow.ParamTypes{"OUT": flow.Float}
+	return opChecked(addr, ins, outs, opfunc)
+}
+func Max(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) *flow.Error {
+		return finite(out, math.Max(flow.ToNum(in["A"]), flow.ToNum(in["B"])))
+	}
+	addr := flow.Address{"math_max", id}
+	ins := flow.ParamTypes{"A": flow.Num, "B": flow.Num}
+	outs := flow.ParamTypes{"OUT": flow.Float}
+	return opChecked(addr, ins, outs, opfunc)
+}
+
+// Limits IN to between Min and Max.
+func Clamp(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) *flow.Error {
+		x, lo, hi := flow.ToNum(in["IN"]), flow.ToNum(in["Min"]), flow.ToNum(in["Max"])
+		if lo > hi {
+			return &flow.Error{flow.VALUE_ERROR, "Min is greater than Max."}
+		}
+		return finite(out, math.Max(lo, math.Min(hi, x)))
+	}
+	addr := flow.Address{"math_clamp", id}
+	ins := flow.ParamTypes{"IN": flow.Num, "Min": flow.Num, "Max": flow.Num}
+	outs := flow.ParamTypes{"OUT": flow.Float}
+	return opChecked(addr, ins, outs, opfunc)
+}
+
+// Constants, blocks without inputs
+func Pi(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opConstant(id, "math_pi", math.Pi)
+}
+func E(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	return opConstant(id, "math_e", math.E)
+}
+
+func opConstant(id flow.InstanceID, name string, val float64) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		out["OUT"] = val
+	}
+	addr := flow.Address{name, id}
+	return opNary(addr, flow.ParamTypes{}, flow.ParamTypes{"OUT": flow.Float}, opfunc)
+}
@@ -0,0 +1,129 @@
+package blocks
+
+import (
+	".."
+	"fmt"
+	"math"
+	"testing"
+)
+
+type mathBlock func(id flow.InstanceID) (flow.FunctionBlock, flow.Address)
+
+// Functions of one input
+func TestMathUnary(t *testing.T) {
+	cases := []struct {
+		blk mathBlock
+		in  interface{}
+		c   float64
+	}{
+		{Sqrt, 4, 2}, {Exp, 0, 1}, {Log, math.E, 1}, {Log10, 100, 2}, {Log2, 8.0, 3},
+		{Abs, -2, 2}, {Floor, 1.5, 1}, {Ceil, 1.2, 2}, {Round, 2.5, 3}, {Round, -2.5, -3},
+		{Sin, 0, 0}, {Cos, 0, 1}, {Tan, 0, 0}, {Asin, 1, math.Pi / 2}, {Acos, 1, 0}, {Atan, 0, 0},
+		{Sinh, 0, 0}, {Cosh, 0, 1}, {Tanh, 0, 0}, {Asinh, 0, 0}, {Acosh, 1, 0}, {Atanh, 0, 0},
+	}
+	for _, cs := range cases {
+		blk, addr := cs.blk(0)
+		fmt.Println("Testing ", addr.Name, "...")
+		err := TestUnary(blk, cs.in, cs.c, "IN", "OUT", addr.Name)
+		if err != nil {
+			t.Error(addr.Name, ": ", err.Info)
+		}
+	}
+}
+
+// Inputs outside the domain raise errors instead of returning NaN
+func TestMathDomain(t *testing.T) {
+	cases := []struct {
+		blk mathBlock
+		in  interface{}
+	}{
+		{Sqrt, -1}, {Log, 0}, {Log10, -1.0}, {Log2, 0}, {Asin, 1.5}, {Acos, -2},
+		{Acosh, 0.5}, {Atanh, 1}, {Exp, 1000},
+	}
+	for _, cs := range cases {
+		blk, addr := cs.blk(0)
+		_, err := RunBlock(blk, flow.ParamValues{"IN": cs.in})
+		if err == nil || err.Class != flow.VALUE_ERROR {
+			t.Error(addr.Name, " accepted ", cs.in)
+		}
+	}
+}
+func TestPow(t *testing.T) {
+	name := "math_pow"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := Pow(0)
+	err := TestBinary(blk, 2, 10, 1024.0, "A", "B", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+	err = TestBinary(blk, -2.0, 3, -8.0, "A", "B", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+	for _, in := range []flow.ParamValues{{"A": -2, "B": 0.5}, {"A": 0, "B": -1}} {
+		if _, err := RunBlock(blk, in); err == nil || err.Class != flow.VALUE_ERROR {
+			t.Error("Pow accepted ", in)
+		}
+	}
+}
+func TestAtan2(t *testing.T) {
+	name := "math_atan2"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := Atan2(0)
+	err := TestBinary(blk, 1, 0, math.Pi/2, "Y", "X", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
+func TestMinMax(t *testing.T) {
+	name := "math_min"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := Min(0)
+	err := TestBinary(blk, 2, 1.5, 1.5, "A", "B", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+	blk, _ = Max(0)
+	err = TestBinary(blk, 2, 1.5, 2.0, "A", "B", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+	for _, mk := range []mathBlock{Min, Max} {
+		blk, addr := mk(0)
+		if _, err := RunBlock(blk, flow.ParamValues{"A": math.NaN(), "B": 1}); err == nil || err.Class != flow.VALUE_ERROR {
+			t.Error(addr.Name, " accepted NaN.")
+		}
+	}
+}
+func TestClamp(t *testing.T) {
+	name := "math_clamp"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := Clamp(0)
+	for in, c := range map[float64]float64{-1: 0, 0.5: 0.5, 3: 1} {
+		err := TestBlock(blk, flow.ParamValues{"IN": in, "Min": 0, "Max": 1}, flow.ParamValues{"OUT": c})
+		if err != nil {
+			t.Error(err.Info)
+		}
+	}
+	_, err := RunBlock(blk, flow.ParamValues{"IN": 0, "Min": 1, "Max": 0})
+	if err == nil || err.Class != flow.VALUE_ERROR {
+		t.Error("Min greater than Max accepted.")
+	}
+	for _, in := range []flow.ParamValues{{"IN": math.NaN(), "Min": 0, "Max": 1}, {"IN": 0.5, "Min": 0, "Max": math.NaN()}} {
+		if _, err := RunBlock(blk, in); err == nil || err.Class != flow.VALUE_ERROR {
+			t.Error("Clamp accepted ", in)
+		}
+	}
+}
+func TestConstants(t *testing.T) {
+	name := "math_pi"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := Pi(0)
+	if err := TestBlock(blk, flow.ParamValues{}, flow.ParamValues{"OUT": math.Pi}); err != nil {
+		t.Error(err.Info)
+	}
+	blk, _ = E(0)
+	if err := TestBlock(blk, flow.ParamValues{}, flow.ParamValues{"OUT": math.E}); err != nil {
+		t.Error(err.Info)
+	}
+}
@@ -0,0 +1,43 @@
+package blocks
+
+import (
+	".."
+	"math/rand"
+)
+
+// Returns a random Float in [0, 1) as OUT.
+func Random(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	runfunc := func(rng *rand.Rand,
+		inputs flow.ParamValues,
+		outputs chan flow.ParamValues,
+		stop chan bool,
+		err chan *flow.Error) {
+		outputs <- flow.ParamValues{"OUT": rng.Float64()}
+	}
+	name := "random"
+	addr := flow.Address{name, id}
+	ins := flow.ParamTypes{}
+	outs := flow.ParamTypes{"OUT": flow.Float}
+	return flow.NewRandomPrimitive(name, runfunc, ins, outs), addr
+}
+
+// Returns a random Int in [0, N) as OUT. N must be positive.
+func RandomInt(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	runfunc := func(rng *rand.Rand,
+		inputs flow.ParamValues,
+		outputs chan flow.ParamValues,
+		stop chan bool,
+		err chan *flow.Error) {
+		n := inputs["N"].(int)
+		if n <= 0 {
+			err <- &flow.Error{flow.VALUE_ERROR, "N must be positive."}
+			return
+		}
+		outputs <- flow.ParamValues{"OUT": rng.Intn(n)}
+	}
+	name := "random_int"
+	addr := flow.Address{name, id}
+	ins := flow.ParamTypes{"N": flow.Int}
+	outs := flow.ParamTypes{"OUT": flow.Int}
+	return flow.NewRandomPrimitive(name, runfunc, ins, outs), addr
+}
@@ -0,0 +1,38 @@
+package blocks
+
+import (
+	".."
+	"testing"
+)
+
+func TestRandom(t *testing.T) {
+	blk, _ := Random(0)
+	for i := 0; i < 10; i++ {
+		out, err := RunBlock(blk, flow.ParamValues{})
+		if err != nil {
+			t.Fatal(err.Info)
+		}
+		if x, ok := out["OUT"].(float64); !ok || x < 0 || x >= 1 {
+			t.Error("Out of range: ", out["OUT"])
+		}
+	}
+	if !flow.IsNondeterministic(blk) {
+		t.Error("Random is not nondeterministic.")
+	}
+}
+func TestRandomInt(t *testing.T) {
+	blk, _ := RandomInt(0)
+	for i := 0; i < 10; i++ {
+		out, err := RunBlock(blk, flow.ParamValues{"N": 3})
+		if err != nil {
+			t.Fatal(err.Info)
+		}
+		if x, ok := out["OUT"].(int); !ok || x < 0 || x >= 3 {
+			t.Error("Out of range: ", out["OUT"])
+		}
+	}
+	_, err := RunBlock(blk, flow.ParamValues{"N": 0})
+	if err == nil || err.Class != flow.VALUE_ERROR {
+		t.Error("N of 0 accepted.")
+	}
+}
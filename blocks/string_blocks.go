@@ -0,0 +1,337 @@
+package blocks
+
+import (
+	".."
+	"container/list"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Positions and lengths in strings count characters, not bytes.
+
+func Concat(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		out["OUT"] = in["A"].(string) + in["B"].(string)
+	}
+	name := "string_concat"
+	addr := flow.Address{name, id}
+	return opBinary(addr, flow.String, flow.String, flow.String, "A", "B", "OUT", name, opfunc), addr
+}
+func StrLen(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		out["OUT"] = utf8.RuneCountInString(in["IN"].(string))
+	}
+	name := "string_len"
+	return opUnary(id, flow.String, flow.Int, name, opfunc)
+}
+
+// Returns Length characters of IN from Start.
+func Substring(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) *flow.Error {
+		runes := []rune(in["IN"].(string))
+		start, length := in["Start"].(int), in["Length"].(int)
+		if start < 0 || length < 0 || start > len(runes) || length > len(runes)-start {
+			return &flow.Error{flow.VALUE_ERROR, "Substring is out of range."}
+		}
+		out["OUT"] = string(runes[start : start+length])
+		return nil
+	}
+	addr := flow.Address{"substring", id}
+	ins := flow.ParamTypes{"IN": flow.String, "Start": flow.Int, "Length": flow.Int}
+	outs := flow.ParamTypes{"OUT": flow.String}
+	return opChecked(addr, ins, outs, opfunc)
+}
+
+// Splits IN around every Sep into a StrArray.
+func Split(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		out["OUT"] = strings.Split(in["IN"].(string), in["Sep"].(string))
+	}
+	name := "string_split"
+	addr := flow.Address{name, id}
+	return opBinary(addr, flow.String, flow.String, flow.StrArray, "IN", "Sep", "OUT", name, opfunc), addr
+}
+
+// Joins the StrArray IN with Sep between each string.
+func Join(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		out["OUT"] = strings.Join(in["IN"].([]string), in["Sep"].(string))
+	}
+	name := "string_join"
+	addr := flow.Address{name, id}
+	return opBinary(addr, flow.StrArray, flow.String, flow.String, "IN", "Sep", "OUT", name, opfunc), addr
+}
+
+// Case and whitespace
+func Upper(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		out["OUT"] = strings.ToUpper(in["IN"].(string))
+	}
+	name := "string_upper"
+	return opUnary(id, flow.String, flow.String, name, opfunc)
+}
+func Lower(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		out["OUT"] = strings.ToLower(in["IN"].(string))
+	}
+	name := "string_lower"
+	return opUnary(id, flow.String, flow.String, name, opfunc)
+}
+func Trim(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		out["OUT"] = strings.TrimSpace(in["IN"].(string))
+	}
+	name := "string_trim"
+	return opUnary(id, flow.String, flow.String, name, opfunc)
+}
+
+// Searching
+func Contains(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		out["OUT"] = strings.Contains(in["IN"].(string), in["Sub"].(string))
+	}
+	name := "string_contains"
+	addr := flow.Address{name, id}
+	return opBinary(addr, flow.String, flow.String, flow.Bool, "IN", "Sub", "OUT", name, opfunc), addr
+}
+
+// Returns the position of the first Sub in IN, or -1 if there is none.
+func IndexOf(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) {
+		s := in["IN"].(string)
+		i := strings.Index(s, in["Sub"].(string))
+		if i > 0 {
+			i = utf8.RuneCountInString(s[:i])
+		}
+		out["OUT"] = i
+	}
+	name := "string_index_of"
+	addr := flow.Address{name, id}
+	return opBinary(addr, flow.String, flow.String, flow.Int, "IN", "Sub", "OUT", name, opfunc), addr
+}
+
+// Regular expressions, in the syntax of package regexp.
+// The maxPatterns most recently used patterns are kept compiled and shared by every block.
+const maxPatterns = 64
+
+var patterns = struct {
+	sync.Mutex
+	order    *list.List // Most recently used first, of *regexp.Regexp
+	compiled map[string]*list.Element
+}{order: list.New(), compiled: make(map[string]*list.Element)}
+
+func compile(pattern string) (*regexp.Regexp, *flow.Error) {
+	patterns.Lock()
+	defer patterns.Unlock()
+	if el, ok := patterns.compiled[pattern]; ok {
+		patterns.order.MoveToFront(el)
+		return el.Value.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, &flow.Error{flow.VALUE_ERROR, "Invalid pattern: " + err.Error()}
+	}
+	patterns.compiled[pattern] = patterns.order.PushFront(re)
+	if patterns.order.Len() > maxPatterns {
+		oldest := patterns.order.Remove(patterns.order.Back())
+		delete(patterns.compiled, oldest.(*regexp.Regexp).String())
+	}
+	return re, nil
+}
+
+func RegexMatch(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) *flow.Error {
+		re, err := compile(in["Pattern"].(string))
+		if err != nil {
+			return err
+		}
+		out["OUT"] = re.MatchString(in["IN"].(string))
+		return nil
+	}
+	addr := flow.Address{"regex_match", id}
+	ins := flow.ParamTypes{"IN": flow.String, "Pattern": flow.String}
+	outs := flow.ParamTypes{"OUT": flow.Bool}
+	return opChecked(addr, ins, outs, opfunc)
+}
+
+// Replaces every match of Pattern in IN with With, which may refer to groups as $1.
+func RegexReplace(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) *flow.Error {
+		re, err := compile(in["Pattern"].(string))
+		if err != nil {
+			return err
+		}
+		out["OUT"] = re.ReplaceAllString(in["IN"].(string), in["With"].(string))
+		return nil
+	}
+	addr := flow.Address{"regex_replace", id}
+	ins := flow.ParamTypes{"IN": flow.String, "Pattern": flow.String, "With": flow.String}
+	outs := flow.ParamTypes{"OUT": flow.String}
+	return opChecked(addr, ins, outs, opfunc)
+}
+
+// Returns every match of Pattern in IN as a StrArray.
+func RegexFindAll(id flow.InstanceID) (flow.FunctionBlock, flow.Address) {
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) *flow.Error {
+		re, err := compile(in["Pattern"].(string))
+		if err != nil {
+			return err
+		}
+		found := re.FindAllString(in["IN"].(string), -1)
+		if found == nil {
+			found = []string{}
+		}
+		out["OUT"] = found
+		return nil
+	}
+	addr := flow.Address{"regex_find_all", id}
+	ins := flow.ParamTypes{"IN": flow.String, "Pattern": flow.String}
+	outs := flow.ParamTypes{"OUT": flow.StrArray}
+	return opChecked(addr, ins, outs, opfunc)
+}
+
+// Formats its inputs Arg0, Arg1... of the given types with the fmt verbs in Format.
+// Verbs which do not match the arguments in number or type raise a VALUE_ERROR.
+func Format(id flow.InstanceID, args ...flow.Type) (flow.FunctionBlock, flow.Address) {
+	ins := flow.ParamTypes{"Format": flow.String}
+	for i, t := range args {
+		ins[fmt.Sprintf("Arg%d", i)] = t
+	}
+	opfunc := func(in flow.ParamValues, out flow.ParamValues) *flow.Error {
+		vals := make([]interface{}, len(args))
+		for i := range args {
+			vals[i] = in[fmt.Sprintf("Arg%d", i)]
+		}
+		format := in["Format"].(string)
+		if err := checkFormat(format, vals); err != nil {
+			return err
+		}
+		out["OUT"] = fmt.Sprintf(format, vals...)
+		return nil
+	}
+	addr := flow.Address{"string_format", id}
+	outs := flow.ParamTypes{"OUT": flow.String}
+	return opChecked(addr, ins, outs, opfunc)
+}
+
+// The verbs fmt accepts for each kind of value, besides v and T.
+// Kinds missing here are not checked.
+var verbKinds = map[reflect.Kind]string{
+	reflect.Bool:    "t",
+	reflect.Int:     "bcdoOqxXU",
+	reflect.Float64: "beEfFgGxX",
+	reflect.String:  "sqxX",
+}
+
+// Walks the verbs of format as fmt does, checking each against the value it uses.
+// Widths and precisions given by * use an Int argument. Unless an argument index
+// such as %[1]d reorders them, every value must be used.
+func checkFormat(format string, vals []interface{}) *flow.Error {
+	arg, reordered := 0, false
+	use := func(verb rune) *flow.Error {
+		if arg >= len(vals) {
+			return &flow.Error{flow.VALUE_ERROR, "Format has more verbs than arguments."}
+		}
+		if !verbFits(verb, vals[arg]) {
+			return &flow.Error{flow.VALUE_ERROR, fmt.Sprintf("Verb %%%c does not fit Arg%d.", verb, arg)}
+		}
+		arg++
+		return nil
+	}
+	i := 0
+	// Reads an argument index such as [1], which the next verb or * uses.
+	index := func() *flow.Error {
+		if i >= len(format) || format[i] != '[' {
+			return nil
+		}
+		end := strings.IndexByte(format[i:], ']')
+		if end < 0 {
+			return &flow.Error{flow.VALUE_ERROR, "Format has an unclosed argument index."}
+		}
+		n, err := strconv.Atoi(format[i+1 : i+end])
+		if err != nil || n < 1 {
+			return &flow.Error{flow.VALUE_ERROR, "Format has an invalid argument index."}
+		}
+		arg, reordered = n-1, true
+		i += end + 1
+		return nil
+	}
+	// Reads a width or precision, either digits or *.
+	width := func() *flow.Error {
+		if i < len(format) && format[i] == '*' {
+			i++
+			return use('*')
+		}
+		for i < len(format) && format[i] >= '0' && format[i] <= '9' {
+			i++
+		}
+		return nil
+	}
+	for ; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		for i < len(format) && strings.IndexByte("+-# 0", format[i]) >= 0 {
+			i++
+		}
+		if err := index(); err != nil {
+			return err
+		}
+		if err := width(); err != nil {
+			return err
+		}
+		if i < len(format) && format[i] == '.' {
+			i++
+			if err := index(); err != nil {
+				return err
+			}
+			if err := width(); err != nil {
+				return err
+			}
+		}
+		if err := index(); err != nil {
+			return err
+		}
+		if i >= len(format) {
+			return &flow.Error{flow.VALUE_ERROR, "Format ends without a verb."}
+		}
+		verb, size := utf8.DecodeRuneInString(format[i:])
+		i += size - 1
+		if verb == '%' {
+			continue
+		}
+		if err := use(verb); err != nil {
+			return err
+		}
+	}
+	if !reordered && arg < len(vals) {
+		return &flow.Error{flow.VALUE_ERROR, "Format has fewer verbs than arguments."}
+	}
+	return nil
+}
+
+// Returns true if fmt can format val with verb. Arrays are formatted element by element.
+func verbFits(verb rune, val interface{}) bool {
+	v := reflect.ValueOf(val)
+	if verb == '*' {
+		return v.Kind() == reflect.Int
+	}
+	if verb == 'v' || verb == 'T' {
+		return true
+	}
+	kind := v.Kind()
+	if kind == reflect.Slice {
+		if verb == 'p' {
+			return true
+		}
+		kind = v.Type().Elem().Kind()
+	}
+	allowed, ok := verbKinds[kind]
+	return !ok || strings.ContainsRune(allowed, verb)
+}
@@ -0,0 +1,185 @@
+package blocks
+
+import (
+	".."
+	"fmt"
+	"math"
+	"testing"
+)
+
+// Strings
+func TestConcat(t *testing.T) {
+	name := "string_concat"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := Concat(0)
+	err := TestBinary(blk, "flow", "graph", "flowgraph", "A", "B", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
+func TestStrLen(t *testing.T) {
+	name := "string_len"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := StrLen(0)
+	err := TestUnary(blk, "héllo", 5, "IN", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
+func TestSubstring(t *testing.T) {
+	name := "substring"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := Substring(0)
+	err := TestBlock(blk, flow.ParamValues{"IN": "héllo", "Start": 1, "Length": 3}, flow.ParamValues{"OUT": "éll"})
+	if err != nil {
+		t.Error(err.Info)
+	}
+	_, err = RunBlock(blk, flow.ParamValues{"IN": "héllo", "Start": 3, "Length": 3})
+	if err == nil || err.Class != flow.VALUE_ERROR {
+		t.Error("Out of range substring accepted.")
+	}
+	_, err = RunBlock(blk, flow.ParamValues{"IN": "héllo", "Start": 1, "Length": math.MaxInt})
+	if err == nil || err.Class != flow.VALUE_ERROR {
+		t.Error("Overflowing substring accepted.")
+	}
+}
+func TestSplit(t *testing.T) {
+	name := "string_split"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := Split(0)
+	err := TestBlock(blk, flow.ParamValues{"IN": "a,b,c", "Sep": ","}, flow.ParamValues{"OUT": []string{"a", "b", "c"}})
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
+func TestJoin(t *testing.T) {
+	name := "string_join"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := Join(0)
+	err := TestBinary(blk, []string{"a", "b", "c"}, "-", "a-b-c", "IN", "Sep", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
+func TestUpper(t *testing.T) {
+	name := "string_upper"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := Upper(0)
+	err := TestUnary(blk, "Flow", "FLOW", "IN", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
+func TestLower(t *testing.T) {
+	name := "string_lower"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := Lower(0)
+	err := TestUnary(blk, "Flow", "flow", "IN", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
+func TestTrim(t *testing.T) {
+	name := "string_trim"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := Trim(0)
+	err := TestUnary(blk, " \tflow\n", "flow", "IN", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
+func TestContains(t *testing.T) {
+	name := "string_contains"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := Contains(0)
+	err := TestBinary(blk, "dataflow", "flow", true, "IN", "Sub", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
+func TestIndexOf(t *testing.T) {
+	name := "string_index_of"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := IndexOf(0)
+	err := TestBinary(blk, "héllo", "llo", 2, "IN", "Sub", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+	err = TestBinary(blk, "hello", "x", -1, "IN", "Sub", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
+
+// Regular expressions
+func TestRegexMatch(t *testing.T) {
+	name := "regex_match"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := RegexMatch(0)
+	err := TestBinary(blk, "node_42", `^node_\d+$`, true, "IN", "Pattern", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+	_, err = RunBlock(blk, flow.ParamValues{"IN": "a", "Pattern": "("})
+	if err == nil || err.Class != flow.VALUE_ERROR {
+		t.Error("Invalid pattern accepted.")
+	}
+	for i := 0; i < 2*maxPatterns; i++ {
+		if _, err := RunBlock(blk, flow.ParamValues{"IN": "a", "Pattern": fmt.Sprintf("a{%d}", i)}); err != nil {
+			t.Error(err.Info)
+		}
+	}
+	if n := patterns.order.Len(); n != maxPatterns || len(patterns.compiled) != maxPatterns {
+		t.Error("Compiled patterns not bounded: ", n)
+	}
+}
+func TestRegexReplace(t *testing.T) {
+	name := "regex_replace"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := RegexReplace(0)
+	ins := flow.ParamValues{"IN": "a1 b22", "Pattern": `([a-z])(\d+)`, "With": "$2$1"}
+	err := TestBlock(blk, ins, flow.ParamValues{"OUT": "1a 22b"})
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
+func TestRegexFindAll(t *testing.T) {
+	name := "regex_find_all"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := RegexFindAll(0)
+	err := TestBlock(blk, flow.ParamValues{"IN": "a1 b22 c", "Pattern": `\d+`}, flow.ParamValues{"OUT": []string{"1", "22"}})
+	if err != nil {
+		t.Error(err.Info)
+	}
+	err = TestBlock(blk, flow.ParamValues{"IN": "abc", "Pattern": `\d+`}, flow.ParamValues{"OUT": []string{}})
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
+func TestFormat(t *testing.T) {
+	name := "string_format"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := Format(0, flow.String, flow.Float)
+	ins := flow.ParamValues{"Format": "%s=%.2f", "Arg0": "x", "Arg1": 1.5}
+	err := TestBlock(blk, ins, flow.ParamValues{"OUT": "x=1.50"})
+	if err != nil {
+		t.Error(err.Info)
+	}
+	ins = flow.ParamValues{"Format": "%s: %[1]q %5.1[2]f%%", "Arg0": "100%!", "Arg1": 1.5}
+	err = TestBlock(blk, ins, flow.ParamValues{"OUT": `100%!: "100%!"   1.5%`})
+	if err != nil {
+		t.Error(err.Info)
+	}
+	for _, format := range []string{"%d", "%s", "%s %f %v", "%s %d", "%s %.*f", "%s %[3]f", "%s %f %"} {
+		ins["Format"] = format
+		_, err = RunBlock(blk, ins)
+		if err == nil || err.Class != flow.VALUE_ERROR {
+			t.Error("Mismatched format accepted: ", format)
+		}
+	}
+	blk, _ = Format(0, flow.Int, flow.NumArray)
+	err = TestBlock(blk, flow.ParamValues{"Format": "%.*f", "Arg0": 1, "Arg1": []float64{1, 2.25}}, flow.ParamValues{"OUT": "[1.0 2.2]"})
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
@@ -1,6 +1,10 @@
 package blocks
 
-import ".."
+import (
+	".."
+	"fmt"
+	"time"
+)
 
 func InputSwitch(id flow.InstanceID, t flow.Type) (flow.FunctionBlock, flow.Address) {
 	runfunc := func(inputs flow.ParamValues,
@@ -41,3 +45,70 @@ func OutputSwitch(id flow.InstanceID, t flow.Type) (flow.FunctionBlock, flow.Add
 	outs := flow.ParamTypes{"A": t, "B": t}
 	return flow.NewPrimitive(name, runfunc, ins, outs), addr
 }
+
+// Forwards whichever of A and B was set, preferring A, so both sides of an OutputSwitch can rejoin.
+// Outputs NoValue if neither was set.
+func Merge(id flow.InstanceID, t flow.Type) (flow.FunctionBlock, flow.Address) {
+	runfunc := func(inputs flow.ParamValues,
+		outputs chan flow.ParamValues,
+		stop chan bool,
+		err chan *flow.Error) {
+		out := make(flow.ParamValues)
+		if inputs["A"] != flow.NoValue {
+			out["OUT"] = inputs["A"]
+		} else {
+			out["OUT"] = inputs["B"]
+		}
+		outputs <- out
+	}
+	name := "merge"
+	addr := flow.Address{name, id}
+	ins := flow.ParamTypes{"A": t, "B": t}
+	outs := flow.ParamTypes{"OUT": t}
+	return flow.NewPrimitiveRule(name, runfunc, ins, outs, flow.MERGE), addr
+}
+
+// Forwards the first of its n inputs, named IN0 to IN{n-1}, to receive a value
+// without waiting for the others. PORT holds the name of the input which won.
+// Which input wins may change from run to run, so the block is nondeterministic.
+func Select(id flow.InstanceID, t flow.Type, n int) (flow.FunctionBlock, flow.Address) {
+	runfunc := func(inputs flow.ParamValues,
+		outputs chan flow.ParamValues,
+		stop chan bool,
+		err chan *flow.Error) {
+		out := make(flow.ParamValues)
+		for name, val := range inputs {
+			out["OUT"] = val
+			out["PORT"] = name
+		}
+		outputs <- out
+	}
+	name := "select"
+	addr := flow.Address{name, id}
+	ins := make(flow.ParamTypes, n)
+	for i := 0; i < n; i++ {
+		ins[fmt.Sprintf("IN%d", i)] = t
+	}
+	outs := flow.ParamTypes{"OUT": t, "PORT": flow.String}
+	return flow.Nondeterministic(flow.NewPrimitiveRule(name, runfunc, ins, outs, flow.ANY_OF)), addr
+}
+
+// Forwards IN after waiting Wait milliseconds, useful as a timeout racing other inputs of a Select.
+func Delay(id flow.InstanceID, t flow.Type) (flow.FunctionBlock, flow.Address) {
+	runfunc := func(inputs flow.ParamValues,
+		outputs chan flow.ParamValues,
+		stop chan bool,
+		err chan *flow.Error) {
+		wait := time.Duration(inputs["Wait"].(int)) * time.Millisecond
+		select {
+		case <-time.After(wait):
+			outputs <- flow.ParamValues{"OUT": inputs["IN"]}
+		case <-stop:
+		}
+	}
+	name := "delay"
+	addr := flow.Address{name, id}
+	ins := flow.ParamTypes{"IN": t, "Wait": flow.Int}
+	outs := flow.ParamTypes{"OUT": t}
+	return flow.NewPrimitive(name, runfunc, ins, outs), addr
+}
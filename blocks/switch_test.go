@@ -40,3 +40,49 @@ func TestOutputSwitch(t *testing.T) {
 		t.Error("Not the right value.")
 	}
 }
+
+// Testing Merge
+func TestMerge(t *testing.T) {
+	name := "Merge"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := Merge(0, flow.Int)
+	b := 5
+	in := flow.ParamValues{"A": flow.NoValue, "B": b}
+	out, err := RunBlock(blk, in)
+	switch {
+	case err != nil:
+		t.Error(err.Info)
+	case out["OUT"] != b:
+		t.Error("Not the right value.")
+	}
+}
+
+// Testing Select
+func TestSelect(t *testing.T) {
+	name := "Select"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := Select(0, flow.Int, 2)
+	a := 5
+	in := flow.ParamValues{"IN1": a}
+	out, err := RunBlock(blk, in)
+	switch {
+	case err != nil:
+		t.Error(err.Info)
+	case out["OUT"] != a:
+		t.Error("Not the right value.")
+	case out["PORT"] != "IN1":
+		t.Error("Not the right port.")
+	}
+}
+
+// Testing Delay
+func TestDelay(t *testing.T) {
+	name := "Delay"
+	fmt.Println("Testing ", name, "...")
+	blk, _ := Delay(0, flow.Int)
+	a, wait := 5, 1
+	err := TestBinary(blk, a, wait, a, "IN", "Wait", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
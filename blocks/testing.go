@@ -1,6 +1,10 @@
 package blocks
 
-import ".."
+import (
+	".."
+	"fmt"
+	"reflect"
+)
 
 func RunBlock(blk flow.FunctionBlock, ins flow.ParamValues) (flow.ParamValues, *flow.FlowError) {
 	// Run a Plus block
@@ -76,3 +80,18 @@ func TestBinary(blk flow.FunctionBlock, a, b, c interface{}, aN, bN, cN, name st
 		return nil
 	}
 }
+
+// Runs blk with ins and checks that it returns every value in c, arrays included.
+func TestBlock(blk flow.FunctionBlock, ins, c flow.ParamValues) *flow.FlowError {
+	out, err := RunBlock(blk, ins)
+	if err != nil {
+		return err
+	}
+	for name, val := range c {
+		if !reflect.DeepEqual(out[name], val) {
+			addr := flow.Address{blk.GetName(), 0}
+			return flow.NewFlowError(flow.VALUE_ERROR, fmt.Sprintf("Returned wrong value for %s: %v.", name, out[name]), addr)
+		}
+	}
+	return nil
+}
@@ -22,8 +22,8 @@ func opUnary(id flow.InstanceID, inT flow.Type, outT flow.Type, outname string,
 		return
 	}
 
-	// Initialize the block and return
-	return flow.NewPrimitive(outname, runfunc, ins, outs), addr
+	// Initialize the block and return, its outputs depend only on its inputs
+	return flow.Pure(flow.NewPrimitive(outname, runfunc, ins, outs)), addr
 }
 
 // Type conversions
@@ -0,0 +1,149 @@
+package flow
+
+import (
+	"container/list"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// FunctionBlocks which implement Purer and return true always return the same outputs
+// for the same inputs and have no side effects, so their outputs can be cached.
+type Purer interface {
+	IsPure() bool
+}
+
+// Returns true if blk declares itself pure.
+func IsPure(blk FunctionBlock) bool {
+	p, ok := blk.(Purer)
+	return ok && p.IsPure()
+}
+
+// Hit and miss counts of a Cache.
+type CacheStats struct {
+	Hits   int
+	Misses int
+	Size   int // Number of entries held
+}
+
+// A bounded least recently used cache of the outputs of pure blocks.
+// Set it on a graph or loop with SetCache, it is consulted before running any pure node inside it.
+type Cache struct {
+	lock    sync.Mutex
+	size    int
+	order   *list.List // Most recently used entries first
+	entries map[cacheKey][]*list.Element
+	stats   CacheStats
+}
+
+// Creates a cache holding at most size entries.
+func NewCache(size int) *Cache {
+	if size < 1 {
+		size = 1
+	}
+	return &Cache{size: size, order: list.New(), entries: make(map[cacheKey][]*list.Element)}
+}
+
+// Returns the hit and miss counts so far.
+func (c *Cache) Stats() CacheStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	stats := c.stats
+	stats.Size = c.order.Len()
+	return stats
+}
+
+// Discards every entry and resets the statistics.
+func (c *Cache) Clear() {
+	c.lock.Lock()
+	c.order.Init()
+	c.entries = make(map[cacheKey][]*list.Element)
+	c.stats = CacheStats{}
+	c.lock.Unlock()
+}
+
+// Entries are found by block and hashed inputs, then compared with the inputs in full.
+type cacheKey struct {
+	blk  interface{} // Identifies the block, the node holding it in a graph
+	hash uint64
+}
+
+type cacheEntry struct {
+	key     cacheKey
+	inputs  ParamValues
+	outputs ParamValues
+}
+
+// Returns a copy of the outputs cached for blk and inputs.
+func (c *Cache) get(blk interface{}, inputs ParamValues) (ParamValues, bool) {
+	if c == nil {
+		return nil, false
+	}
+	key := cacheKey{blk, hashValues(inputs)}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, el := range c.entries[key] {
+		entry := el.Value.(*cacheEntry)
+		if reflect.DeepEqual(entry.inputs, inputs) {
+			c.order.MoveToFront(el)
+			c.stats.Hits += 1
+			return entry.outputs.Copy(), true
+		}
+	}
+	c.stats.Misses += 1
+	return nil, false
+}
+
+// Caches the outputs blk returned for inputs, evicting the least recently used entry if full.
+func (c *Cache) put(blk interface{}, inputs, outputs ParamValues) {
+	if c == nil {
+		return
+	}
+	key := cacheKey{blk, hashValues(inputs)}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, el := range c.entries[key] {
+		if reflect.DeepEqual(el.Value.(*cacheEntry).inputs, inputs) {
+			return
+		}
+	}
+	entry := &cacheEntry{key, inputs.Copy(), outputs.Copy()}
+	c.entries[key] = append(c.entries[key], c.order.PushFront(entry))
+	if c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+// Must hold lock.
+func (c *Cache) remove(el *list.Element) {
+	key := el.Value.(*cacheEntry).key
+	c.order.Remove(el)
+	els := c.entries[key]
+	for i := range els {
+		if els[i] == el {
+			els = append(els[:i], els[i+1:]...)
+			break
+		}
+	}
+	if len(els) == 0 {
+		delete(c.entries, key)
+	} else {
+		c.entries[key] = els
+	}
+}
+
+// Hashes the names, types and values of vals in a stable order.
+func hashValues(vals ParamValues) uint64 {
+	names := make([]string, 0, len(vals))
+	for name := range vals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h := fnv.New64a()
+	for _, name := range names {
+		fmt.Fprintf(h, "%s=%T:%v;", name, vals[name], vals[name])
+	}
+	return h.Sum64()
+}
@@ -0,0 +1,133 @@
+package flow
+
+import (
+	"fmt"
+)
+
+const SELECTOR_NAME = "SELECTOR"
+
+// A structure which holds several branches and runs only the one
+// chosen by the value of its SELECTOR input.
+// Every branch shares the parameters of the structure, minus the selector.
+type Case struct {
+	name     string
+	selector Type
+	inputs   ParamTypes
+	outputs  ParamTypes
+
+	branches map[interface{}]FunctionBlock // Connects a selector value to the branch it runs
+	fallback FunctionBlock                 // Run when no branch matches the selector, may be nil
+
+	env *env // Settings shared with every branch
+}
+
+// Creates a case structure whose selector is of type Bool, Int or String.
+func NewCase(name string, selector Type, inputs, outputs ParamTypes) (*Case, *Error) {
+	_, exists := inputs[SELECTOR_NAME]
+	switch {
+	case selector != Bool && selector != Int && selector != String:
+		return nil, &Error{TYPE_ERROR, "Selector must be of type Bool, Int or String."}
+	case exists:
+		return nil, &Error{ALREADY_EXISTS_ERROR, "SELECTOR is already an input."}
+	}
+	branches := make(map[interface{}]FunctionBlock)
+	return &Case{name, selector, inputs.Copy(), outputs.Copy(), branches, nil, newEnv()}, nil
+}
+
+// FunctionBlock Fields
+func (c Case) GetName() string { return c.name }
+func (c Case) GetParams() (inputs ParamTypes, outputs ParamTypes) {
+	inputs = c.inputs.Copy()
+	inputs[SELECTOR_NAME] = c.selector
+	return inputs, c.outputs.Copy()
+}
+
+// Adds blk as the branch run when the selector equals key.
+func (c *Case) AddCase(key interface{}, blk FunctionBlock) *Error {
+	_, exists := c.branches[key]
+	switch {
+	case !CheckType(c.selector, key):
+		return &Error{TYPE_ERROR, "key is not the same type as the selector."}
+	case exists:
+		return &Error{ALREADY_EXISTS_ERROR, "A branch for key already exists."}
+	}
+	if err := c.checkBranch(blk); err != nil {
+		return err
+	}
+	c.branches[key] = adopt(blk, c.env, Address{fmt.Sprint(key), 0})
+	return nil
+}
+
+// Sets blk as the branch run when no other branch matches the selector.
+func (c *Case) SetDefault(blk FunctionBlock) *Error {
+	if err := c.checkBranch(blk); err != nil {
+		return err
+	}
+	c.fallback = adopt(blk, c.env, Address{blk.GetName(), 1})
+	return nil
+}
+
+// Checks that blk has exactly the parameters of the structure.
+func (c Case) checkBranch(blk FunctionBlock) *Error {
+	ins, outs := blk.GetParams()
+	if !sameParams(ins, c.inputs) {
+		return &Error{TYPE_ERROR, "Branch inputs do not match the inputs of the structure."}
+	}
+	if !sameParams(outs, c.outputs) {
+		return &Error{TYPE_ERROR, "Branch outputs do not match the outputs of the structure."}
+	}
+	return nil
+}
+
+// Returns true if both maps have the same names linked to compatible types.
+func sameParams(a, b ParamTypes) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, t1 := range a {
+		t2, exists := b[name]
+		if !exists || !CheckSame(t1, t2) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c Case) Run(inputs ParamValues, outputs chan ParamValues, stop chan bool, err chan *FlowError, id InstanceID) {
+	c.runWatched(nil, inputs, outputs, stop, err, id)
+}
+
+// Runs the chosen branch, letting w observe this run only.
+func (c Case) runWatched(w Observer, inputs ParamValues, outputs chan ParamValues, stop chan bool, err chan *FlowError, id InstanceID) {
+	ADDR := Address{c.GetName(), id}
+
+	// Choose the branch
+	sel, exists := inputs[SELECTOR_NAME]
+	if !exists {
+		c.env.raise(err, NewFlowError(DNE_ERROR, "Not all inputs satisfied: "+SELECTOR_NAME, ADDR))
+		return
+	}
+	blk, found := c.branches[sel]
+	if !found {
+		blk = c.fallback
+	}
+	if blk == nil {
+		c.env.raise(err, NewFlowError(VALUE_ERROR, "No branch for selector value.", ADDR))
+		return
+	}
+
+	// Pass every input but the selector
+	b_inputs := inputs.Copy()
+	delete(b_inputs, SELECTOR_NAME)
+
+	// Run only the chosen branch
+	b_out, b_stop, b_err := blockRun(blk, w, b_inputs, id)
+	select {
+	case data_out := <-b_out:
+		outputs <- data_out
+	case <-stop:
+		b_stop <- true
+	case temp_err := <-b_err:
+		err <- temp_err
+	}
+}
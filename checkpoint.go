@@ -0,0 +1,48 @@
+package flow
+
+import (
+	"encoding/gob"
+	"os"
+)
+
+// The state of a loop run between two iterations, enough to resume it.
+// Values of custom types must be registered with encoding/gob before writing or reading it.
+type Checkpoint struct {
+	Loop      string      // Name of the loop which wrote it
+	Iteration int         // Index of the next iteration to run
+	Registers ParamValues // Values of the inner inputs fed by registers, stacked ones included
+	Outputs   ParamValues // Outputs collected so far
+	Done      bool        // True if the DONE output already ended the loop
+}
+
+// Reads a checkpoint written by a loop with SetCheckpoint.
+func ReadCheckpoint(path string) (*Checkpoint, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	cp := &Checkpoint{}
+	if err := gob.NewDecoder(f).Decode(cp); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+// Writes the checkpoint next to path, then moves it over path,
+// so a run ending while writing never leaves half a checkpoint behind.
+func (cp *Checkpoint) write(path string) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(f).Encode(cp); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,193 @@
+package flow
+
+import (
+	"sync"
+)
+
+// Pauses nodes before they fire during the runs it starts, and records the values passed on edges.
+// Add it to a graph or loop with AddObserver to probe its edges, and run the block with Start.
+// Nodes only pause during runs started by the debugger, each run pausing and resuming on its own.
+type Debugger struct {
+	BaseObserver
+	lock   sync.Mutex
+	breaks map[Address]bool
+	probes []*Probe
+}
+
+func NewDebugger() *Debugger {
+	return &Debugger{breaks: make(map[Address]bool)}
+}
+
+// Pauses every node at addr before it fires.
+func (d *Debugger) SetBreakpoint(addr Address) {
+	d.lock.Lock()
+	d.breaks[addr] = true
+	d.lock.Unlock()
+}
+
+func (d *Debugger) ClearBreakpoint(addr Address) {
+	d.lock.Lock()
+	delete(d.breaks, addr)
+	d.lock.Unlock()
+}
+
+// Records every value passed from src_addr[src_param] to dst_addr[dst_param].
+// The inputs and outputs of a graph belong to the address of the graph with ID 0.
+func (d *Debugger) AddProbe(src_addr Address, src_param string, dst_addr Address, dst_param string) *Probe {
+	p := &Probe{src: ParamAddress{Name: src_param, Addr: src_addr}, dst: ParamAddress{Name: dst_param, Addr: dst_addr}}
+	d.lock.Lock()
+	d.probes = append(d.probes, p)
+	d.lock.Unlock()
+	return p
+}
+
+// Runs blk in the background and returns a handle to drive the run.
+func (d *Debugger) Start(blk FunctionBlock, inputs ParamValues, id InstanceID) *DebugRun {
+	r := &DebugRun{d: d, pauses: make(chan *Pause), halt: make(chan struct{}), done: make(chan struct{})}
+	out, stop, err := blockRun(blk, r, inputs, id)
+	go func() {
+		select {
+		case r.outputs = <-out:
+		case r.err = <-err:
+		case <-r.halt:
+			// Stop the run, unless it is already returning
+			select {
+			case stop <- true:
+			case <-out:
+			case <-err:
+			}
+		}
+		close(r.done)
+	}()
+	return r
+}
+
+func (d *Debugger) breakpoint(addr Address) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.breaks[addr]
+}
+
+func (d *Debugger) OnValuePassed(src, dst ParamAddress, val interface{}) {
+	d.lock.Lock()
+	probes := d.probes
+	d.lock.Unlock()
+	for _, p := range probes {
+		if p.src.Name == src.Name && p.src.Addr == src.Addr && p.dst.Name == dst.Name && p.dst.Addr == dst.Addr {
+			p.record(val)
+		}
+	}
+}
+
+// A node paused before firing.
+type Pause struct {
+	Addr   Address
+	Inputs ParamValues // The values which were waiting on the inputs of the node
+	resume chan struct{}
+}
+
+// Records the values passed on one edge.
+type Probe struct {
+	lock   sync.Mutex
+	src    ParamAddress
+	dst    ParamAddress
+	values []interface{}
+}
+
+// Returns every value passed on the edge so far, oldest first.
+func (p *Probe) Values() []interface{} {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return append([]interface{}{}, p.values...)
+}
+
+func (p *Probe) record(val interface{}) {
+	p.lock.Lock()
+	p.values = append(p.values, val)
+	p.lock.Unlock()
+}
+
+// A handle on a run started by a Debugger. It observes only its own run.
+type DebugRun struct {
+	BaseObserver
+	d        *Debugger
+	pauses   chan *Pause
+	lock     sync.Mutex
+	stepping bool   // Pause before the next node fires, whatever its address
+	current  *Pause // The pause the run is waiting on, nil if none
+	halt     chan struct{}
+	once     sync.Once
+	done     chan struct{}
+	outputs  ParamValues
+	err      *FlowError
+}
+
+func (r *DebugRun) OnNodeStart(addr Address, ins ParamValues) {
+	r.lock.Lock()
+	pause := r.stepping
+	r.lock.Unlock()
+	if !pause && !r.d.breakpoint(addr) {
+		return
+	}
+
+	// Wait to be resumed, or for the run to be stopped or to end
+	p := &Pause{Addr: addr, Inputs: ins.Copy(), resume: make(chan struct{}, 1)}
+	select {
+	case r.pauses <- p:
+	case <-r.halt:
+		return
+	case <-r.done:
+		return
+	}
+	select {
+	case <-p.resume:
+	case <-r.halt:
+	case <-r.done:
+	}
+}
+
+// Waits until a node pauses and returns it, or returns false once the run has ended.
+func (r *DebugRun) Wait() (*Pause, bool) {
+	select {
+	case p := <-r.pauses:
+		r.lock.Lock()
+		r.current = p
+		r.lock.Unlock()
+		return p, true
+	case <-r.done:
+		return nil, false
+	}
+}
+
+// Resumes the paused node and runs until the next breakpoint.
+func (r *DebugRun) Continue() {
+	r.resume(false)
+}
+
+// Resumes the paused node and pauses again before the next node fires.
+func (r *DebugRun) Step() {
+	r.resume(true)
+}
+
+func (r *DebugRun) resume(step bool) {
+	r.lock.Lock()
+	r.stepping = step
+	p := r.current
+	r.current = nil
+	r.lock.Unlock()
+	if p != nil {
+		p.resume <- struct{}{}
+	}
+}
+
+// Stops the run.
+func (r *DebugRun) Stop() {
+	r.once.Do(func() { close(r.halt) })
+}
+
+// Waits for the run to end and returns its outputs or error.
+// Both are nil if the run was stopped.
+func (r *DebugRun) Result() (ParamValues, *FlowError) {
+	<-r.done
+	return r.outputs, r.err
+}
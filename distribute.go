@@ -0,0 +1,250 @@
+package flow
+
+import (
+	"encoding/gob"
+	"net"
+	"sync"
+)
+
+// Kinds of messages exchanged between a Cluster and its workers:
+const (
+	runMessage    = iota // Runs a block, from the cluster
+	stopMessage   = iota // Stops a running block, from the cluster
+	resultMessage = iota // Outputs or error of a block, from the worker
+)
+
+// Values of custom types must be registered with encoding/gob on both sides.
+type message struct {
+	Kind   int
+	ID     uint64      // Pairs a result or stop with its run
+	Block  Address     // The node to run
+	Values ParamValues // Inputs of a run, outputs of a result
+	Err    *FlowError
+}
+
+// Runs the nodes placed on workers with Graph.Place.
+// Set it on a graph or loop with SetCluster.
+type Cluster struct {
+	lock    sync.Mutex
+	workers map[string]*remote
+}
+
+func NewCluster() *Cluster {
+	return &Cluster{workers: make(map[string]*remote)}
+}
+
+// Connects to the Worker serving on address of network, like "unix" or "tcp",
+// and names it so nodes can be placed on it.
+func (c *Cluster) Connect(name, network, address string) *Error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, exists := c.workers[name]; exists {
+		return &Error{ALREADY_EXISTS_ERROR, "A worker with this name is already connected."}
+	}
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return &Error{REMOTE_ERROR, "Could not connect to worker " + name + ": " + err.Error()}
+	}
+	r := &remote{name: name, conn: conn, enc: gob.NewEncoder(conn), calls: make(map[uint64]chan message)}
+	c.workers[name] = r
+	go r.read()
+	return nil
+}
+
+// Disconnects every worker. Blocks still running on them are stopped and fail.
+func (c *Cluster) Close() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for name, r := range c.workers {
+		r.conn.Close()
+		delete(c.workers, name)
+	}
+}
+
+// Returns the worker named name, or nil if none is connected.
+func (c *Cluster) worker(name string) *remote {
+	if c == nil {
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.workers[name]
+}
+
+// The connection to a worker, seen from the cluster.
+type remote struct {
+	name   string
+	conn   net.Conn
+	lock   sync.Mutex
+	enc    *gob.Encoder
+	calls  map[uint64]chan message // Receive the result of each run in progress, closed if it is lost
+	next   uint64
+	failed string // Why the connection was lost, empty while it is up
+}
+
+// Runs the block of the node at addr on the worker, like FunctionBlock.Run.
+func (r *remote) run(e *env, addr Address, inputs ParamValues,
+	outputs chan ParamValues, stop chan bool, err chan *FlowError) {
+	if r == nil {
+		e.raise(err, NewFlowError(DNE_ERROR, "Node is placed on a worker which is not connected.", addr))
+		return
+	}
+	r.lock.Lock()
+	if r.failed != "" {
+		r.lock.Unlock()
+		e.raise(err, NewFlowError(REMOTE_ERROR, r.failed, addr))
+		return
+	}
+	r.next += 1
+	id := r.next
+	reply := make(chan message, 1)
+	r.calls[id] = reply
+	if send_err := r.enc.Encode(message{runMessage, id, addr, inputs, nil}); send_err != nil {
+		r.conn.Close() // Fails every run in progress, this one included
+	}
+	r.lock.Unlock()
+
+	select {
+	case msg, ok := <-reply:
+		if !ok {
+			r.lock.Lock()
+			failed := r.failed
+			r.lock.Unlock()
+			e.raise(err, NewFlowError(REMOTE_ERROR, failed, addr))
+		} else if msg.Err != nil {
+			e.raise(err, msg.Err)
+		} else {
+			outputs <- msg.Values
+		}
+	case <-stop:
+		r.lock.Lock()
+		delete(r.calls, id)
+		if r.failed == "" {
+			r.enc.Encode(message{Kind: stopMessage, ID: id})
+		}
+		r.lock.Unlock()
+	}
+}
+
+// Hands results to the runs waiting for them until the connection is lost,
+// then fails every run still waiting.
+func (r *remote) read() {
+	dec := gob.NewDecoder(r.conn)
+	for {
+		var msg message
+		if err := dec.Decode(&msg); err != nil {
+			r.lock.Lock()
+			r.failed = "Lost connection to worker " + r.name + "."
+			for id, reply := range r.calls {
+				close(reply)
+				delete(r.calls, id)
+			}
+			r.lock.Unlock()
+			r.conn.Close()
+			return
+		}
+		r.lock.Lock()
+		if reply, exists := r.calls[msg.ID]; exists {
+			reply <- msg
+			delete(r.calls, msg.ID)
+		}
+		r.lock.Unlock()
+	}
+}
+
+// Runs blocks for the clusters connected to it, usually in a process of its own.
+// Blocks are built from the constructor registered under the name of the node they run for.
+type Worker struct {
+	lock   sync.Mutex
+	blocks map[string]func(id InstanceID) FunctionBlock
+}
+
+func NewWorker() *Worker {
+	return &Worker{blocks: make(map[string]func(id InstanceID) FunctionBlock)}
+}
+
+// Runs nodes named name with the blocks blk returns.
+func (w *Worker) Register(name string, blk func(id InstanceID) FunctionBlock) {
+	w.lock.Lock()
+	w.blocks[name] = blk
+	w.lock.Unlock()
+}
+
+// Serves clusters connecting to l until it is closed.
+func (w *Worker) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go w.serve(conn)
+	}
+}
+
+// Serves one cluster, stopping its blocks once it disconnects.
+func (w *Worker) serve(conn net.Conn) {
+	dec := gob.NewDecoder(conn)
+	enc := gob.NewEncoder(conn)
+	var lock sync.Mutex                   // Guards enc and running
+	running := make(map[uint64]chan bool) // Closed to stop each run in progress
+	for {
+		var msg message
+		if err := dec.Decode(&msg); err != nil {
+			break
+		}
+		lock.Lock()
+		switch msg.Kind {
+		case runMessage:
+			halt := make(chan bool)
+			running[msg.ID] = halt
+			go func(msg message) {
+				out := w.run(msg, halt)
+				lock.Lock()
+				defer lock.Unlock()
+				if _, exists := running[msg.ID]; exists {
+					delete(running, msg.ID)
+					enc.Encode(out)
+				}
+			}(msg)
+		case stopMessage:
+			if halt, exists := running[msg.ID]; exists {
+				close(halt)
+				delete(running, msg.ID)
+			}
+		}
+		lock.Unlock()
+	}
+
+	lock.Lock()
+	for id, halt := range running {
+		close(halt)
+		delete(running, id)
+	}
+	lock.Unlock()
+	conn.Close()
+}
+
+// Runs the block asked for by msg until it returns or halt is closed.
+func (w *Worker) run(msg message, halt chan bool) message {
+	out := message{Kind: resultMessage, ID: msg.ID}
+	w.lock.Lock()
+	build, exists := w.blocks[msg.Block.Name]
+	w.lock.Unlock()
+	if !exists {
+		out.Err = NewFlowError(DNE_ERROR, "Block is not registered on the worker.", msg.Block)
+		return out
+	}
+
+	f_out, f_stop, f_err := BlockRun(build(msg.Block.ID), msg.Values, msg.Block.ID)
+	select {
+	case out.Values = <-f_out:
+	case out.Err = <-f_err:
+	case <-halt:
+		select {
+		case f_stop <- true:
+		case <-f_out:
+		case <-f_err:
+		}
+	}
+	return out
+}
@@ -0,0 +1,213 @@
+package flow
+
+import (
+	"log/slog"
+	"math/rand"
+	"sync"
+)
+
+// Settings of a graph, loop or case structure. Blocks nested inside it use them
+// unless they set their own, and it uses those of its parent for anything it does not set.
+type env struct {
+	parent *env    // Settings of the block this one is nested in, nil at the top
+	at     Address // Identifies the block inside its parent
+
+	sched     Scheduler    // Starts the nodes of graphs, one goroutine per node if nil everywhere
+	observers observers    // Receive events from every run, along with the observers of the parent
+	logger    *slog.Logger // Logs every run, nothing is logged if nil
+	cache     *Cache       // Holds the outputs of pure nodes, nothing is cached if nil
+	rng       *rand.Rand   // Random numbers of random primitives, shared by all runs if nil everywhere
+	cluster   *Cluster     // Runs the nodes placed on workers
+	tracer    *Tracer      // The observer added by SetTracer, nil if none
+
+	has_logger bool // True once logger was set here, even to nil
+	has_cache  bool // True once cache was set here, even to nil
+
+	lock sync.Mutex
+	tape *tape // Records or replays the current run, nil if none
+}
+
+// Used when no logger is set.
+var discard = slog.New(slog.DiscardHandler)
+
+func newEnv() *env {
+	return &env{}
+}
+
+// Returns the scheduler to start nodes with.
+func (e *env) scheduler() Scheduler {
+	for ; e != nil; e = e.parent {
+		if e.sched != nil {
+			return e.sched
+		}
+	}
+	return GoroutineScheduler{}
+}
+
+// Returns the observer to send events to, or nil if there are none.
+// w observes only the current run, it may be nil.
+// Callers check for nil so nothing is built for events nobody receives.
+func (e *env) observer(w Observer) Observer {
+	var obs observers
+	if t := e.taping(); t != nil {
+		obs = append(obs, tapeAt{t: t, path: e.path()})
+	}
+	for ; e != nil; e = e.parent {
+		obs = append(obs, e.observers...)
+	}
+	if w != nil {
+		obs = append(obs, w)
+	}
+	if len(obs) == 0 {
+		return nil
+	}
+	return obs
+}
+
+// Returns the tape recording or replaying the current run, or nil if there is none.
+func (e *env) taping() *tape {
+	for ; e != nil; e = e.parent {
+		e.lock.Lock()
+		t := e.tape
+		e.lock.Unlock()
+		if t != nil {
+			return t
+		}
+	}
+	return nil
+}
+
+// Returns the addresses identifying the blocks e is nested in, from the outermost, ending with its own.
+func (e *env) path() []Address {
+	var path []Address
+	for ; e != nil && e.parent != nil; e = e.parent {
+		path = append([]Address{e.at}, path...)
+	}
+	return path
+}
+
+func (e *env) setTape(t *tape) {
+	e.lock.Lock()
+	e.tape = t
+	e.lock.Unlock()
+}
+
+// Implemented by blocks holding settings shared with the blocks nested inside them.
+type settled interface {
+	settings() *env
+}
+
+func (g Graph) settings() *env { return g.env }
+func (l Loop) settings() *env  { return l.env }
+func (c Case) settings() *env  { return c.env }
+
+// Returns the cache for the outputs of pure nodes, or nil if caching is off.
+func (e *env) caching() *Cache {
+	for ; e != nil; e = e.parent {
+		if e.has_cache {
+			return e.cache
+		}
+	}
+	return nil
+}
+
+func (e *env) setCache(cache *Cache) {
+	e.cache, e.has_cache = cache, true
+}
+
+// Returns the source of random numbers for random primitives.
+func (e *env) random() *rand.Rand {
+	for ; e != nil; e = e.parent {
+		if e.rng != nil {
+			return e.rng
+		}
+	}
+	return globalRand
+}
+
+// Returns the logger to log runs with.
+func (e *env) log() *slog.Logger {
+	for ; e != nil; e = e.parent {
+		if e.has_logger {
+			if e.logger == nil {
+				return discard
+			}
+			return e.logger
+		}
+	}
+	return discard
+}
+
+func (e *env) setLogger(logger *slog.Logger) {
+	e.logger, e.has_logger = logger, true
+}
+
+// Replaces the tracer added by an earlier call among the observers. A nil tracer only removes it.
+func (e *env) setTracer(tracer *Tracer) {
+	if e.tracer != nil {
+		for i, obs := range e.observers {
+			if obs == Observer(e.tracer) {
+				e.observers = append(e.observers[:i:i], e.observers[i+1:]...)
+				break
+			}
+		}
+	}
+	e.tracer = tracer
+	if tracer != nil {
+		e.observers = append(e.observers, tracer)
+	}
+}
+
+// Returns the cluster running the nodes placed on workers, or nil if none is set.
+func (e *env) clustered() *Cluster {
+	for ; e != nil; e = e.parent {
+		if e.cluster != nil {
+			return e.cluster
+		}
+	}
+	return nil
+}
+
+// Adds attributes to every record of log, unless nothing is logged anyway.
+func logWith(log *slog.Logger, key string, val interface{}) *slog.Logger {
+	if log == discard {
+		return log
+	}
+	return log.With(key, val)
+}
+
+// Lets observers know about an error raised by a block, then returns it on err.
+func (e *env) raise(err chan *FlowError, flow_err *FlowError) {
+	e.log().Error("Block raised an error", "node", flow_err.Addr, "class", flow_err.Class, "error", flow_err.Info)
+	if obs := e.observer(nil); obs != nil {
+		obs.OnError(flow_err)
+	}
+	err <- flow_err
+}
+
+// Implemented by blocks which pass an observer of a single run on to the blocks nested inside them.
+type watchable interface {
+	runWatched(w Observer, inputs ParamValues, outputs chan ParamValues, stop chan bool, err chan *FlowError, id InstanceID)
+}
+
+// Runs blk like Run, letting w observe the run if blk passes it on.
+func runWatched(blk FunctionBlock, w Observer, inputs ParamValues, outputs chan ParamValues, stop chan bool, err chan *FlowError, id InstanceID) {
+	if wb, ok := blk.(watchable); ok && w != nil {
+		wb.runWatched(w, inputs, outputs, stop, err, id)
+		return
+	}
+	blk.Run(inputs, outputs, stop, err, id)
+}
+
+// Makes blk use the settings in e for anything it does not set itself.
+// at identifies blk inside the block e belongs to. Returns blk so it can be stored directly.
+func adopt(blk FunctionBlock, e *env, at Address) FunctionBlock {
+	switch b := blk.(type) {
+	case PrimitiveBlock:
+		b.env = e
+		return b
+	case settled:
+		b.settings().parent, b.settings().at = e, at
+	}
+	return blk
+}
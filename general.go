@@ -3,6 +3,7 @@ package flow
 import (
 	"io/ioutil"
 	"log"
+	"log/slog"
 	"os"
 	"reflect"
 )
@@ -48,6 +49,11 @@ type Address struct {
 	ID   InstanceID
 }
 
+// Logs an address as a group of its name and ID.
+func (a Address) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("name", a.Name), slog.Int("id", int(a.ID)))
+}
+
 // Used in maps to reference FunctionBlock parameters
 // FIXME: Is this still needed with the new Graph structure?
 type ParamAddress struct {
@@ -67,6 +73,8 @@ const (
 	ALREADY_EXISTS_ERROR = iota // Something already exists
 	NOT_READY_ERROR      = iota // Not ready to do what you wanted
 	VALUE_ERROR          = iota // Value is not acceptable
+	REMOTE_ERROR         = iota // A worker running part of a graph could not be reached
+	IO_ERROR             = iota // Reading or writing a file failed
 )
 
 // Used to declare a general error.
@@ -96,24 +104,64 @@ func NewFlowError(Class int, Info string, Addr Address) *FlowError {
 	return &FlowError{&Error{Class, Info}, Addr}
 }
 
+// Passed in place of a value by outputs which were not set, like the unused side of an OutputSwitch.
+// Nodes which receive it on a required input are skipped and pass it on through all of their outputs.
+var NoValue interface{} = noValue{}
+
+type noValue struct{}
+
+func (v noValue) String() string { return "NoValue" }
+
+// Decides when a node in a graph runs its block.
+type FiringRule int
+
+// Firing rules:
+const (
+	ALL_OF FiringRule = iota // Run once every input has a value, skip if any input is NoValue
+	MERGE  FiringRule = iota // Run once every input has arrived, skip only if all inputs are NoValue
+	ANY_OF FiringRule = iota // Run as soon as one input has a value, passing only that input
+)
+
+// FunctionBlocks which implement Firer choose the rule by which their node runs in a graph.
+// Blocks which do not implement it use ALL_OF.
+type Firer interface {
+	GetFiringRule() FiringRule
+}
+
+// FunctionBlocks which implement Nondeterminer and return true may return different outputs
+// for the same inputs, like random numbers or the winner of a race.
+type Nondeterminer interface {
+	IsNondeterministic() bool
+}
+
+// Returns true if blk declares itself nondeterministic.
+func IsNondeterministic(blk FunctionBlock) bool {
+	n, ok := blk.(Nondeterminer)
+	return ok && n.IsNondeterministic()
+}
+
 // Types
 const (
-	Float    Type = "Float"
-	String   Type = "String"
-	Int      Type = "Int"
-	Num      Type = "Num"
-	Bool     Type = "Bool"
-	NumArray Type = "NumArray"
+	Float     Type = "Float"
+	String    Type = "String"
+	Int       Type = "Int"
+	Num       Type = "Num"
+	Bool      Type = "Bool"
+	NumArray  Type = "NumArray"
+	StrArray  Type = "StrArray"
+	BoolArray Type = "BoolArray"
 )
 
 // A map of Type objects linked to the reflect types that are valid for them.
 var Types = map[Type][]reflect.Type{
-	String:   {reflect.TypeOf("")},
-	Int:      {reflect.TypeOf(5)},
-	Float:    {reflect.TypeOf(5.1)},
-	Num:      {reflect.TypeOf(5), reflect.TypeOf(5.1)},
-	Bool:     {reflect.TypeOf(true)},
-	NumArray: {reflect.TypeOf([]float64{})}}
+	String:    {reflect.TypeOf("")},
+	Int:       {reflect.TypeOf(5)},
+	Float:     {reflect.TypeOf(5.1)},
+	Num:       {reflect.TypeOf(5), reflect.TypeOf(5.1)},
+	Bool:      {reflect.TypeOf(true)},
+	NumArray:  {reflect.TypeOf([]float64{})},
+	StrArray:  {reflect.TypeOf([]string{})},
+	BoolArray: {reflect.TypeOf([]bool{})}}
 
 // Checks if all keys in params are present in values
 // And that all values are of their appropriate types as labeled in in params
@@ -185,6 +233,8 @@ func ToNum(n interface{}) float64 {
 // If logmode is "none" it does not print to screen or to file.
 // If logmode is "screen" it prints to the screen.
 // All other logmodes define the file location.
+// If the file can not be created it prints to stderr instead.
+// Graphs and loops log with log/slog instead, see Graph.SetLogger.
 // Source: http://changelog.ca/log/2015/03/09/golang
 func CreateLogger(logMode, tag string) *log.Logger {
 	switch logMode {
@@ -197,7 +247,9 @@ func CreateLogger(logMode, tag string) *log.Logger {
 	default:
 		out, err := os.Create(logMode)
 		if nil != err {
-			panic(err.Error())
+			logger := log.New(os.Stderr, tag, log.Lshortfile)
+			logger.Println("Could not create log file, logging to stderr:", err)
+			return logger
 		} else {
 			return log.New(out, tag, log.Lshortfile)
 		}
@@ -1,61 +1,302 @@
 package flow
 
+import (
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
 type Node struct {
-	f       FunctionBlock
-	inputs  map[string]*InParameter
-	outputs map[string]*OutParameter
+	addr      Address
+	f         FunctionBlock
+	inputs    map[string]*InParameter
+	outputs   map[string]*OutParameter
+	out_names []string       // Names of outputs in the order they are passed
+	ctrl_ins  []*InParameter // Receive a token once each node this one must follow has completed
+	ctrl_outs []*InParameter // Passed a token once this node has completed
+	state     *arrivals      // Tracks which inputs have arrived during a run, set on a copy of the node
+	env       *env           // Settings of the graph running the node, set on the same copy
+	log       *slog.Logger   // Logs with the graph and node addresses, set on the same copy
+	origin    *Node          // The node this is a copy of, identifies it in caches
+	watch     Observer       // Observes only the current run, set on the same copy, may be nil
+	worker    string         // The worker of the cluster running the block, empty to run it here
 }
 
 func (n Node) Run(stop chan bool, err chan *FlowError, id InstanceID) {
-	logger := CreateLogger("none", "[INFO]")
-	blk_ins := make(ParamValues)
-	logger.Println(n.f.GetName(), "\tReading Params... ")
-	for name, in_param := range n.inputs {
-		blk_ins[name] = <-in_param.val
-		logger.Println(n.f.GetName(), "Found: ", name)
+	log := n.log
+	if log == nil {
+		log = logWith(n.env.log(), "node", n.addr)
+	}
+
+	// Do not start once the graph has stopped
+	select {
+	case <-stop:
+		return
+	default:
+	}
+
+	log.Debug("Reading inputs")
+	var blk_ins ParamValues
+	var dead int
+	var ok bool
+	if n.rule() == ANY_OF {
+		blk_ins, dead, ok = n.readFirst(stop)
+	} else {
+		blk_ins, dead, ok = n.readAll(stop)
+	}
+	if !ok {
+		return
+	}
+
+	// Wait for every node this one must follow
+	for _, ctrl := range n.ctrl_ins {
+		select {
+		case <-ctrl.val:
+		case <-stop:
+			return
+		}
+	}
+
+	// Skip the block if its inputs are dead, and let the nodes after it know
+	obs := n.env.observer(n.watch)
+	if n.skip(dead) {
+		log.Debug("Skipping", "dead", dead)
+		for _, name := range n.out_names {
+			n.outputs[name].pass(NoValue, obs)
+		}
+		n.complete()
+		return
 	}
 
-	logger.Println(n.f.GetName(), "\tRunning... ")
+	log.Debug("Running")
+	if obs != nil {
+		obs.OnNodeStart(n.addr, blk_ins)
+
+		// Observers may have paused the node until the graph stopped
+		select {
+		case <-stop:
+			return
+		default:
+		}
+	}
+	start := time.Now()
 	blk_outs := make(chan ParamValues, 1)
 	blk_stop := make(chan bool, 1)
 	blk_err := make(chan *FlowError, 1)
-	go n.f.Run(blk_ins, blk_outs, blk_stop, blk_err, id)
 
-	logger.Println(n.f.GetName(), "\tWaiting... ")
+	// Nondeterministic blocks return their recorded outputs when replaying
+	tape := n.env.taping()
+	if tape != nil && !IsNondeterministic(n.f) {
+		tape = nil
+	}
+	// Pure blocks return cached outputs for inputs they have already seen
+	cache := n.env.caching()
+	if n.origin == nil || !IsPure(n.f) {
+		cache = nil
+	}
+
+	var path []Address
+	if tape != nil {
+		path = n.env.path()
+	}
+
+	if recorded, ok := tape.replay(path, n.addr); ok {
+		blk_outs <- recorded
+	} else if cached, ok := cache.get(n.origin, blk_ins); ok {
+		log.Debug("Cached")
+		blk_outs <- cached
+	} else if n.worker != "" {
+		go n.env.clustered().worker(n.worker).run(n.env, n.addr, blk_ins, blk_outs, blk_stop, blk_err)
+	} else if p, ok := n.f.(PrimitiveBlock); ok {
+		go p.run(nil, blk_ins, blk_outs, blk_stop, blk_err, id) // The node lets observers know itself
+	} else {
+		go runWatched(n.f, n.watch, blk_ins, blk_outs, blk_stop, blk_err, id)
+	}
+
 	select {
 	case out := <-blk_outs:
-		for name, out_param := range n.outputs {
+		log.Debug("Finished", "duration", time.Since(start))
+		tape.record(path, n.addr, out)
+		cache.put(n.origin, blk_ins, out)
+		if obs != nil {
+			obs.OnNodeFinish(n.addr, blk_ins, out, time.Since(start))
+		}
+		// Do not pass anything on once the graph has stopped
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		for _, name := range n.out_names {
+			out_param := n.outputs[name]
 			val, exists := out[name]
+			log.Debug("Passing output", "parameter", name, "value", val)
 			if exists {
-				out_param.PassValue(val)
+				out_param.pass(val, obs)
+			} else {
+				out_param.pass(NoValue, obs)
 			}
 		}
+		n.complete()
 	case <-stop:
+		log.Debug("Stopping")
 		blk_stop <- true
 	case temp := <-blk_err:
-		err <- temp
+		if f, ok := obs.(FailureObserver); ok {
+			f.OnNodeFail(n.addr, blk_ins, temp, time.Since(start))
+		}
+		select {
+		case err <- temp:
+		case <-stop:
+		}
 	}
-	logger.Println(n.f.GetName(), "\tDone!")
 	return
 }
 
+// Lets every node which must follow this one know it has completed.
+func (n Node) complete() {
+	for _, ctrl := range n.ctrl_outs {
+		ctrl.receive(true)
+	}
+}
+
+// Reads every input, counting the ones which received NoValue.
+func (n Node) readAll(stop chan bool) (blk_ins ParamValues, dead int, ok bool) {
+	blk_ins = make(ParamValues)
+	for name, in_param := range n.inputs {
+		select {
+		case val := <-in_param.val:
+			blk_ins[name] = val
+			if val == NoValue {
+				dead += 1
+			}
+		case <-stop:
+			return nil, 0, false
+		}
+	}
+	return blk_ins, dead, true
+}
+
+// Reads only the first input which received a value during this run.
+// If none did, every input is counted as dead.
+func (n Node) readFirst(stop chan bool) (blk_ins ParamValues, dead int, ok bool) {
+	first := ""
+	if n.state != nil {
+		first = n.state.first
+	}
+	if first == "" {
+		return make(ParamValues), len(n.inputs), true
+	}
+	select {
+	case val := <-n.inputs[first].val:
+		return ParamValues{first: val}, 0, true
+	case <-stop:
+		return nil, 0, false
+	}
+}
+
+// Returns the rule by which this node runs its block.
+func (n Node) rule() FiringRule {
+	if f, ok := n.f.(Firer); ok {
+		return f.GetFiringRule()
+	}
+	return ALL_OF
+}
+
+// Returns true if the block should not run given the number of inputs which received NoValue.
+func (n Node) skip(dead int) bool {
+	switch n.rule() {
+	case MERGE, ANY_OF:
+		return dead > 0 && dead == len(n.inputs)
+	default:
+		return dead > 0
+	}
+}
+
+// Counts the inputs of a node which have arrived during a graph run, to know when it is ready.
+type arrivals struct {
+	lock  sync.Mutex
+	data  int    // Number of data inputs still missing
+	ctrl  int    // Number of control tokens still missing
+	any   bool   // True if the node runs as soon as one input has a value
+	first string // Name of the first data input to receive something other than NoValue
+	fired bool
+}
+
+// Records an input arriving, and returns true if the node has just become ready to run.
+func (a *arrivals) arrive(name string, val interface{}, ctrl bool) bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if ctrl {
+		a.ctrl -= 1
+	} else {
+		a.data -= 1
+		if val != NoValue && a.first == "" {
+			a.first = name
+		}
+	}
+	return a.fire()
+}
+
+// Marks the node as fired if it is ready and has not fired yet. Must hold lock.
+func (a *arrivals) fire() bool {
+	if a.fired || a.ctrl > 0 || (a.data > 0 && !(a.any && a.first != "")) {
+		return false
+	}
+	a.fired = true
+	return true
+}
+
 type InParameter struct {
 	t      Type
+	addr   ParamAddress
 	val    chan interface{}
 	source Edge
+	arrive func(val interface{}) // Called after each value arrives, set for the inputs of nodes during a run
+	lock   sync.Mutex
+}
+
+// Stores val and lets the owner of this parameter know it arrived.
+func (p *InParameter) receive(val interface{}) {
+	p.val <- val
+	p.lock.Lock()
+	arrive := p.arrive
+	p.lock.Unlock()
+	if arrive != nil {
+		arrive(val)
+	}
+}
+
+// Sets the function called after each value arrives.
+func (p *InParameter) onArrive(arrive func(val interface{})) {
+	p.lock.Lock()
+	p.arrive = arrive
+	p.lock.Unlock()
 }
 
 type OutParameter struct {
 	t     Type
+	addr  ParamAddress
 	edges []*InParameter
 }
 
 func (o OutParameter) PassValue(val interface{}) {
 	for _, in_param := range o.edges {
-		in_param.val <- val
+		in_param.receive(val)
 	}
 }
 
+// Passes val on, letting obs know about every edge it travels first.
+func (o OutParameter) pass(val interface{}, obs Observer) {
+	if obs != nil {
+		for _, in_param := range o.edges {
+			obs.OnValuePassed(o.addr, in_param.addr, val)
+		}
+	}
+	o.PassValue(val)
+}
+
 type Constant struct {
 	t    Type
 	val  interface{}
@@ -63,7 +304,21 @@ type Constant struct {
 }
 
 func (c Constant) PassValue(val interface{}) {
-	c.edge.val <- val
+	c.edge.receive(val)
+}
+
+// An edge which carries the value its source produced during the previous run of the graph,
+// or its initial value during the first run. Allows cycles inside a graph.
+type Feedback struct {
+	t     Type
+	init  interface{}
+	val   interface{}  // The value passed on the next run
+	store *InParameter // Receives the value the source produces during this run
+	edge  *InParameter
+}
+
+func (f Feedback) PassValue(val interface{}) {
+	f.edge.receive(val)
 }
 
 type Edge interface {
@@ -71,25 +326,32 @@ type Edge interface {
 }
 
 type Graph struct {
-	name    string
-	nodes   map[Address]*Node
-	consts  []*Constant
-	inputs  map[string]*OutParameter
-	outputs map[string]*InParameter
+	name     string
+	nodes    map[Address]*Node
+	consts   []*Constant
+	inputs   map[string]*OutParameter
+	outputs  map[string]*InParameter
+	controls map[Address][]Address // Connects a node to the nodes which must run after it
+	feedback []*Feedback
+	env      *env      // Settings shared with every block nested inside the graph
+	pure     bool      // True if the graph always returns the same outputs for the same inputs
+	order    []Address // Nodes in the order they were added, so runs can follow a stable order
 }
 
-func createInParams(inputs ParamTypes) map[string]*InParameter {
+func createInParams(inputs ParamTypes, owner Address) map[string]*InParameter {
 	ins := make(map[string]*InParameter, len(inputs))
 	for name, t := range inputs {
-		ins[name] = &InParameter{t, make(chan interface{}, 1), nil}
+		addr := ParamAddress{name, owner, t, true}
+		ins[name] = &InParameter{t, addr, make(chan interface{}, 1), nil, nil, sync.Mutex{}}
 	}
 	return ins
 }
 
-func createOutParams(outputs ParamTypes) map[string]*OutParameter {
+func createOutParams(outputs ParamTypes, owner Address) map[string]*OutParameter {
 	outs := make(map[string]*OutParameter, len(outputs))
 	for name, t := range outputs {
-		outs[name] = &OutParameter{t, make([]*InParameter, 0)}
+		addr := ParamAddress{name, owner, t, false}
+		outs[name] = &OutParameter{t, addr, make([]*InParameter, 0)}
 	}
 	return outs
 }
@@ -105,13 +367,15 @@ func NewGraph(name string, inputs, outputs ParamTypes) (*Graph, *Error) {
 	}
 
 	// Create input and output parameter structures from map
-	ins := createOutParams(inputs)
-	outs := createInParams(outputs)
+	ins := createOutParams(inputs, Address{name, 0})
+	outs := createInParams(outputs, Address{name, 0})
 
-	// Create placeholders for nodes and constants
+	// Create placeholders for nodes, constants and control edges
 	nodes := make(map[Address]*Node)
 	consts := make([]*Constant, 0)
-	return &Graph{name, nodes, consts, ins, outs}, nil
+	controls := make(map[Address][]Address)
+	feedback := make([]*Feedback, 0)
+	return &Graph{name, nodes, consts, ins, outs, controls, feedback, newEnv(), false, nil}, nil
 }
 
 func (g Graph) FindInParam(param_name string, param_addr Address) (*InParameter, *Error) {
@@ -163,9 +427,15 @@ func (g *Graph) AddNode(blk FunctionBlock, addr Address) *Error {
 	_, exists := g.nodes[addr]
 	if !exists {
 		in_map, out_map := blk.GetParams()
-		inputs := createInParams(in_map)
-		outputs := createOutParams(out_map)
-		g.nodes[addr] = &Node{blk, inputs, outputs}
+		inputs := createInParams(in_map, addr)
+		outputs := createOutParams(out_map, addr)
+		out_names := make([]string, 0, len(outputs))
+		for name := range outputs {
+			out_names = append(out_names, name)
+		}
+		sort.Strings(out_names)
+		g.nodes[addr] = &Node{addr: addr, f: adopt(blk, g.env, addr), inputs: inputs, outputs: outputs, out_names: out_names}
+		g.order = append(g.order, addr)
 		return nil
 	} else {
 		return &Error{ALREADY_EXISTS_ERROR, "blk is already a node in Graph."}
@@ -193,6 +463,41 @@ func (g *Graph) AddEdge(out_addr Address, out_param_name string,
 	}
 }
 
+// out_addr[out_param_name] -> in_addr[in_param_name] on the next run
+// The input receives init on the first run, and afterwards the value the output
+// produced during the previous run. The output keeps its last value if it was not set.
+func (g *Graph) AddFeedbackEdge(out_addr Address, out_param_name string,
+	in_addr Address, in_param_name string, init interface{}) *Error {
+	out_param, out_err := g.FindOutParam(out_param_name, out_addr)
+	in_param, in_err := g.FindInParam(in_param_name, in_addr)
+	switch {
+	case out_err != nil:
+		return out_err
+	case in_err != nil:
+		return in_err
+	case in_param.source != nil:
+		return &Error{ALREADY_EXISTS_ERROR, "in_param already has a source."}
+	case !CheckSame(out_param.t, in_param.t):
+		return &Error{TYPE_ERROR, "in_param and out_param incompatible types."}
+	case !CheckType(in_param.t, init):
+		return &Error{TYPE_ERROR, "init is not the same type as in_param."}
+	default:
+		store := &InParameter{out_param.t, in_param.addr, make(chan interface{}, 1), out_param, nil, sync.Mutex{}}
+		fb := &Feedback{in_param.t, init, init, store, in_param}
+		out_param.edges = append(out_param.edges, store) // Keep the output until the next run
+		in_param.source = fb                             // Set the input source
+		g.feedback = append(g.feedback, fb)
+		return nil
+	}
+}
+
+// Sets every feedback edge back to its initial value.
+func (g *Graph) ResetFeedback() {
+	for _, fb := range g.feedback {
+		fb.val = fb.init
+	}
+}
+
 // self[self_param_name] -> in_addr[in_param_name]
 func (g *Graph) LinkIn(self_param_name string, in_param_name string, in_addr Address) *Error {
 	in_param, err := g.FindInParam(in_param_name, in_addr)
@@ -226,6 +531,149 @@ func (g *Graph) LinkOut(out_addr Address, out_param_name string, self_param_name
 	}
 }
 
+// before_addr -> after_addr
+// Makes after_addr wait until before_addr has completed, even though no data passes between them.
+// Will return an error if the nodes are already ordered this way or if it would create a cycle.
+func (g *Graph) AddControlEdge(before_addr, after_addr Address) *Error {
+	before, before_exists := g.nodes[before_addr]
+	after, after_exists := g.nodes[after_addr]
+	switch {
+	case !before_exists || !after_exists:
+		return &Error{DNE_ERROR, "Node does not exist."}
+	case before_addr == after_addr || g.reaches(after_addr, before_addr):
+		return &Error{VALUE_ERROR, "Control edge would create a cycle."}
+	}
+	for _, addr := range g.controls[before_addr] {
+		if addr == after_addr {
+			return &Error{ALREADY_EXISTS_ERROR, "Control edge already exists."}
+		}
+	}
+	ctrl := &InParameter{"", ParamAddress{}, make(chan interface{}, 1), nil, nil, sync.Mutex{}}
+	before.ctrl_outs = append(before.ctrl_outs, ctrl)
+	after.ctrl_ins = append(after.ctrl_ins, ctrl)
+	g.controls[before_addr] = append(g.controls[before_addr], after_addr)
+	return nil
+}
+
+// Makes each node wait until the one before it in addrs has completed.
+func (g *Graph) AddSequence(addrs ...Address) *Error {
+	for i := 1; i < len(addrs); i++ {
+		if err := g.AddControlEdge(addrs[i-1], addrs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns a copy of all control edges, connecting each node to the nodes which must run after it
+func (g Graph) GetControlEdges() map[Address][]Address {
+	out := make(map[Address][]Address, len(g.controls))
+	for addr, afters := range g.controls {
+		out[addr] = append([]Address{}, afters...)
+	}
+	return out
+}
+
+// Returns true if to_addr runs after from_addr through any data or control edge.
+func (g Graph) reaches(from_addr, to_addr Address) bool {
+	owners := make(map[*InParameter]Address)
+	for addr, nd := range g.nodes {
+		for _, in_param := range nd.inputs {
+			owners[in_param] = addr
+		}
+	}
+	visited := make(map[Address]bool)
+	stack := []Address{from_addr}
+	for len(stack) > 0 {
+		addr := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if addr == to_addr {
+			return true
+		} else if visited[addr] {
+			continue
+		}
+		visited[addr] = true
+		for _, out_param := range g.nodes[addr].outputs {
+			for _, in_param := range out_param.edges {
+				if next, exists := owners[in_param]; exists {
+					stack = append(stack, next)
+				}
+			}
+		}
+		stack = append(stack, g.controls[addr]...)
+	}
+	return false
+}
+
+// Sets the scheduler which starts the nodes of this graph, and of every block nested inside it.
+func (g *Graph) SetScheduler(sched Scheduler) {
+	g.env.sched = sched
+}
+
+// Sends the events of every run of this graph, and of every block nested inside it, to obs.
+func (g *Graph) AddObserver(obs Observer) {
+	g.env.observers = append(g.env.observers, obs)
+}
+
+// Records every node run and loop iteration of this graph, and of every block nested inside it, in tracer.
+// Same as AddObserver, except that it replaces the tracer set before. A nil tracer turns tracing off.
+func (g *Graph) SetTracer(tracer *Tracer) {
+	g.env.setTracer(tracer)
+}
+
+// Runs the nodes of this graph, and of every block nested inside it, one at a time in a stable order
+// on the goroutine waiting for the graph, and seeds every random primitive inside it from seed.
+// Runs started after the same call return the same outputs in the same order.
+func (g *Graph) SetDeterministic(seed int64) {
+	g.env.sched = NewSerialScheduler()
+	g.env.rng = newRand(seed)
+}
+
+// Caches the outputs of every pure node of this graph, and of the blocks nested inside it, in cache.
+// A nil cache turns caching off. Nested blocks which set their own cache use it instead.
+func (g *Graph) SetCache(cache *Cache) {
+	g.env.setCache(cache)
+}
+
+// Declares that the graph always returns the same outputs for the same inputs and has no side effects,
+// so graphs it is nested in may cache its outputs.
+func (g *Graph) SetPure(pure bool) {
+	g.pure = pure
+}
+
+// Returns true if the graph was declared pure.
+func (g Graph) IsPure() bool { return g.pure }
+
+// Logs every run of this graph, and of every block nested inside it, to logger.
+// Records carry the graph and node addresses, loop iterations and parameter names.
+// Steps are logged at the debug level, errors at the error level. A nil logger logs nothing.
+func (g *Graph) SetLogger(logger *slog.Logger) {
+	g.env.setLogger(logger)
+}
+
+// Runs the node at addr, and every block nested inside it, on the worker named worker
+// of the cluster set with SetCluster. An empty name runs it here again.
+// The worker builds the block from the constructor registered under the name of addr.
+// Outputs, errors and stops are the same, but observers only see the node, not the blocks inside it.
+func (g *Graph) Place(addr Address, worker string) *Error {
+	nd, exists := g.nodes[addr]
+	if !exists {
+		return &Error{DNE_ERROR, "Node does not exist in graph."}
+	}
+	nd.worker = worker
+	return nil
+}
+
+// Sets the cluster running the nodes placed on workers, in this graph and every block nested inside it.
+func (g *Graph) SetCluster(c *Cluster) {
+	g.env.cluster = c
+}
+
+// Runs the nodes of this graph, and of every block nested inside it, on a pool of n workers.
+func (g *Graph) SetMaxConcurrency(n int) {
+	g.SetScheduler(NewWorkerPool(n))
+}
+
 // Returns copies of all parameters in FunctionBlock
 func (g Graph) GetParams() (inputs, outputs ParamTypes) {
 	inputs = make(ParamTypes)
@@ -246,68 +694,113 @@ func (g Graph) Run(inputs ParamValues,
 	outputs chan ParamValues,
 	stop chan bool,
 	err chan *FlowError, id InstanceID) {
+	g.runWatched(nil, inputs, outputs, stop, err, id)
+}
 
+// Runs the graph, letting w observe this run only.
+func (g Graph) runWatched(w Observer, inputs ParamValues, outputs chan ParamValues, stop chan bool, err chan *FlowError, id InstanceID) {
 	ADDR := Address{g.GetName(), id}
-	logger := CreateLogger("none", "[INFO]")
+	log := logWith(g.env.log(), "graph", ADDR)
+	sched := g.env.scheduler()
 
-	// Pass all inputs to input parameters
-	logger.Println("Passing Inputs... ", inputs)
-	for name, val := range inputs {
-		param_in, exists := g.inputs[name]
-		if exists {
-			param_in.PassValue(val)
-		} else {
-			err <- &FlowError{&Error{DNE_ERROR, "Not all inputs fulfilled."}, ADDR}
-			logger.Println("Not all inputs fulfilled.")
+	// Check all inputs before passing any of them
+	for name := range inputs {
+		if _, exists := g.inputs[name]; !exists {
+			g.env.raise(err, &FlowError{&Error{DNE_ERROR, "Not all inputs fulfilled."}, ADDR})
 			return
 		}
 	}
 
+	// Get every node ready to start once its inputs arrive
+	log.Debug("Scheduling nodes", "nodes", len(g.nodes))
+	g.clear()
+	all_stop := make(chan bool)
+	blk_err := make(chan *FlowError, 1)
+	g.schedule(sched, w, all_stop, blk_err, log)
+
+	allStop := func() {
+		log.Debug("Stopping")
+		close(all_stop)
+	}
+
+	// Pass all inputs to input parameters
+	obs := g.env.observer(w)
+	names := make([]string, 0, len(inputs))
+	for name := range inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		val := inputs[name]
+		log.Debug("Passing input", "parameter", name, "value", val)
+		g.inputs[name].pass(val, obs)
+	}
+
 	// Load Constants
-	logger.Println("Passing Constants...")
-	logger.Println("Constants: ", g.consts)
 	for _, c := range g.consts {
-		logger.Println("Passing... ", c)
+		log.Debug("Passing constant", "node", c.edge.addr.Addr, "parameter", c.edge.addr.Name, "value", c.val)
 		c.PassValue(c.val)
 	}
 
-	// Run all nodes
-	logger.Println("Starting Nodes...")
-	all_stop := make([](chan bool), 0, len(g.nodes))
-	blk_err := make(chan *FlowError, 1)
-	for addr, nd := range g.nodes {
-		blk_stop := make(chan bool, 1)
-		go nd.Run(blk_stop, blk_err, addr.ID)
-		all_stop = append(all_stop, blk_stop)
+	// Load values fed back from the last run
+	for _, fb := range g.feedback {
+		fb.PassValue(fb.val)
 	}
 
-	allStop := func() {
-		logger.Println("Stopping...")
-		for _, blk_stop := range all_stop {
-			blk_stop <- true
+	// Waits for a value on in_param, helping the scheduler run nodes in the meantime
+	wait := func(in_param *InParameter) (interface{}, bool) {
+		for {
+			// Run waiting nodes first, so a serial scheduler runs every node before the graph returns
+			select {
+			case <-sched.Pending():
+				sched.Help()
+				continue
+			default:
+			}
+
+			select {
+			case <-stop:
+				log.Info("Stopped")
+				allStop()
+				return nil, false
+			case temp_err := <-blk_err:
+				err <- temp_err
+				allStop()
+				return nil, false
+			case temp := <-in_param.val:
+				return temp, true
+			case <-sched.Pending():
+				sched.Help()
+			}
 		}
 	}
 
 	// Wait for all output parameters to be set
-	logger.Println("Waiting...")
 	data_out := make(ParamValues)
 	for name, out_param := range g.outputs {
-		logger.Println(name)
-		select {
-		case <-stop:
-			allStop()
-			return
-		case temp_err := <-blk_err:
-			err <- temp_err
-			logger.Println(temp_err)
-			allStop()
+		temp, ok := wait(out_param)
+		if !ok {
 			return
-		case temp := <-out_param.val:
-			logger.Println(temp)
+		}
+		log.Debug("Received output", "parameter", name, "value", temp)
+		if temp != NoValue { // Outputs which were not set are left out
 			data_out[name] = temp
 		}
-		logger.Println("-------------------------------")
-		logger.Println(data_out)
+	}
+
+	// Wait for every value to feed back into the next run
+	fb_vals := make([]interface{}, len(g.feedback))
+	for i, fb := range g.feedback {
+		temp, ok := wait(fb.store)
+		if !ok {
+			return
+		}
+		fb_vals[i] = temp
+	}
+	for i, fb := range g.feedback {
+		if fb_vals[i] != NoValue {
+			fb.val = fb_vals[i]
+		}
 	}
 
 	// If you made it this far, return the output
@@ -316,6 +809,71 @@ func (g Graph) Run(inputs ParamValues,
 	return
 }
 
+// Prepares every node to be started by sched as soon as its inputs arrive during this run.
+func (g Graph) schedule(sched Scheduler, w Observer, stop chan bool, err chan *FlowError, log *slog.Logger) {
+	ready := make([]func(), 0)
+	for _, addr := range g.order {
+		nd, id := g.nodes[addr], addr.ID
+		state := &arrivals{data: len(nd.inputs), ctrl: len(nd.ctrl_ins), any: nd.rule() == ANY_OF}
+		run := *nd // Nodes still running from an earlier run keep their own state
+		run.state, run.env, run.log = state, g.env, logWith(log, "node", addr)
+		run.origin, run.watch = nd, w
+		start := func() {
+			sched.Go(func() { run.Run(stop, err, id) })
+		}
+		for name, in_param := range nd.inputs {
+			name := name
+			in_param.onArrive(func(val interface{}) {
+				if state.arrive(name, val, false) {
+					start()
+				}
+			})
+		}
+		for _, ctrl := range nd.ctrl_ins {
+			ctrl.onArrive(func(val interface{}) {
+				if state.arrive("", val, true) {
+					start()
+				}
+			})
+		}
+		if state.fire() { // Nodes without any inputs are ready right away
+			ready = append(ready, start)
+		}
+	}
+
+	// Only start nodes once every other node is listening for its inputs
+	for _, start := range ready {
+		start()
+	}
+}
+
+// Discards values left behind by an earlier run which stopped before they were read.
+func (g Graph) clear() {
+	drain := func(in_param *InParameter) {
+		for {
+			select {
+			case <-in_param.val:
+			default:
+				return
+			}
+		}
+	}
+	for _, nd := range g.nodes {
+		for _, in_param := range nd.inputs {
+			drain(in_param)
+		}
+		for _, ctrl := range nd.ctrl_ins {
+			drain(ctrl)
+		}
+	}
+	for _, out_param := range g.outputs {
+		drain(out_param)
+	}
+	for _, fb := range g.feedback {
+		drain(fb.store)
+	}
+}
+
 // Checks if all keys in params are present in values
 // And that all values are of their appropriate types as labeled in in params
 func (g Graph) checkTypes(values ParamValues, params ParamMap) (ok bool) {
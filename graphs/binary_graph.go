@@ -25,6 +25,7 @@ func Nand(id flow.InstanceID) (*flow.Graph, flow.Address) {
 	graph.LinkIn("B", "B", and_addr)
 	graph.AddEdge(and_addr, "OUT", not_addr, "IN")
 	graph.LinkOut(not_addr, "OUT", "OUT")
+	graph.SetPure(true)
 
 	return graph, addr
 }
@@ -0,0 +1,122 @@
+package graphs
+
+import (
+	".."
+	"../blocks"
+	"testing"
+)
+
+// Caching
+
+func TestCache(t *testing.T) {
+	name := "power_loop"
+	blk, _ := Power(0)
+	cache := flow.NewCache(100)
+	blk.SetCache(cache)
+
+	// The second run finds every multiplication in the cache
+	for i := 0; i < 2; i++ {
+		err := blocks.TestBinary(blk, 2.0, 3, 8.0, "X", "N", "OUT", name)
+		if err != nil {
+			t.Error(err.Info)
+		}
+	}
+	if stats := cache.Stats(); stats.Hits != 3 || stats.Misses != 3 || stats.Size != 3 {
+		t.Error("Wrong stats: ", stats)
+	}
+
+	// Disabled
+	blk.SetCache(nil)
+	err := blocks.TestBinary(blk, 2.0, 3, 8.0, "X", "N", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+	if stats := cache.Stats(); stats.Hits != 3 || stats.Misses != 3 {
+		t.Error("Cache used while disabled: ", stats)
+	}
+}
+func TestCacheEviction(t *testing.T) {
+	name := "power_loop"
+	blk, _ := Power(0)
+	cache := flow.NewCache(2)
+	blk.SetCache(cache)
+
+	// Each iteration evicts the entry the next run needs first
+	for i := 0; i < 2; i++ {
+		err := blocks.TestBinary(blk, 2.0, 3, 8.0, "X", "N", "OUT", name)
+		if err != nil {
+			t.Error(err.Info)
+		}
+	}
+	if stats := cache.Stats(); stats.Hits != 0 || stats.Misses != 6 || stats.Size != 2 {
+		t.Error("Wrong stats: ", stats)
+	}
+}
+func TestCachePureGraph(t *testing.T) {
+	name := "nested_nand"
+	ins := flow.ParamTypes{"A": flow.Bool, "B": flow.Bool}
+	outs := flow.ParamTypes{"OUT": flow.Bool}
+	graph, _ := flow.NewGraph(name, ins, outs)
+	nand, nand_addr := Nand(0)
+	graph.AddNode(nand, nand_addr)
+	graph.LinkIn("A", "A", nand_addr)
+	graph.LinkIn("B", "B", nand_addr)
+	graph.LinkOut(nand_addr, "OUT", "OUT")
+	cache := flow.NewCache(10)
+	graph.SetCache(cache)
+
+	// The nand graph and both of its nodes miss, then the nand graph hits without running its nodes
+	for i := 0; i < 2; i++ {
+		err := blocks.TestBinary(graph, true, true, false, "A", "B", "OUT", name)
+		if err != nil {
+			t.Error(err.Info)
+		}
+	}
+	if stats := cache.Stats(); stats.Hits != 1 || stats.Misses != 3 {
+		t.Error("Wrong stats: ", stats)
+	}
+}
+func TestCacheNested(t *testing.T) {
+	name := "nested_nand"
+	ins := flow.ParamTypes{"A": flow.Bool, "B": flow.Bool}
+	outs := flow.ParamTypes{"OUT": flow.Bool}
+	newOuter := func(nand *flow.Graph, nand_addr flow.Address) *flow.Graph {
+		graph, _ := flow.NewGraph(name, ins, outs)
+		graph.AddNode(nand, nand_addr)
+		graph.LinkIn("A", "A", nand_addr)
+		graph.LinkIn("B", "B", nand_addr)
+		graph.LinkOut(nand_addr, "OUT", "OUT")
+		return graph
+	}
+
+	// Disabled on the nand graph only, so only the outer graph caches the nand graph
+	nand, nand_addr := Nand(0)
+	graph := newOuter(nand, nand_addr)
+	cache := flow.NewCache(10)
+	graph.SetCache(cache)
+	nand.SetCache(nil)
+	for i := 0; i < 2; i++ {
+		err := blocks.TestBinary(graph, true, true, false, "A", "B", "OUT", name)
+		if err != nil {
+			t.Error(err.Info)
+		}
+	}
+	if stats := cache.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Error("Nested nodes cached while disabled: ", stats)
+	}
+
+	// Set on the nand graph before it was added, so only its nodes are cached
+	nand, nand_addr = Nand(0)
+	cache = flow.NewCache(10)
+	nand.SetCache(cache)
+	graph = newOuter(nand, nand_addr)
+	for i := 0; i < 2; i++ {
+		err := blocks.TestBinary(graph, true, true, false, "A", "B", "OUT", name)
+		if err != nil {
+			t.Error(err.Info)
+		}
+	}
+	if stats := cache.Stats(); stats.Hits != 2 || stats.Misses != 2 {
+		t.Error("Wrong stats: ", stats)
+	}
+}
@@ -0,0 +1,60 @@
+package graphs
+
+import (
+	".."
+	"../blocks"
+)
+
+// Increments IN when SELECTOR is 0, decrements it when SELECTOR is 1, and inverts it otherwise.
+func Adjust(id flow.InstanceID) (*flow.Case, flow.Address) {
+	ins := flow.ParamTypes{"IN": flow.Int}
+	outs := flow.ParamTypes{"OUT": flow.Int}
+	c, _ := flow.NewCase("adjust_case", flow.Int, ins, outs)
+	addr := flow.Address{"adjust_case", id}
+
+	// Create Branches
+	inc, _ := blocks.Inc(0)
+	dec, _ := blocks.Dec(0)
+	inv, _ := blocks.InvInt(0)
+
+	c.AddCase(0, inc)
+	c.AddCase(1, dec)
+	c.SetDefault(inv)
+
+	return c, addr
+}
+
+// Increments IN when SELECTOR is true, and decrements it otherwise.
+func Step(id flow.InstanceID) (*flow.Case, flow.Address) {
+	ins := flow.ParamTypes{"IN": flow.Int}
+	outs := flow.ParamTypes{"OUT": flow.Int}
+	c, _ := flow.NewCase("step_case", flow.Bool, ins, outs)
+	addr := flow.Address{"step_case", id}
+
+	// Create Branches
+	inc, _ := blocks.Inc(0)
+	dec, _ := blocks.Dec(0)
+
+	c.AddCase(true, inc)
+	c.AddCase(false, dec)
+
+	return c, addr
+}
+
+// Increments IN when SELECTOR is "up" and decrements it when SELECTOR is "down".
+// It has no default branch, so any other SELECTOR fails.
+func Nudge(id flow.InstanceID) (*flow.Case, flow.Address) {
+	ins := flow.ParamTypes{"IN": flow.Int}
+	outs := flow.ParamTypes{"OUT": flow.Int}
+	c, _ := flow.NewCase("nudge_case", flow.String, ins, outs)
+	addr := flow.Address{"nudge_case", id}
+
+	// Create Branches
+	inc, _ := blocks.Inc(0)
+	dec, _ := blocks.Dec(0)
+
+	c.AddCase("up", inc)
+	c.AddCase("down", dec)
+
+	return c, addr
+}
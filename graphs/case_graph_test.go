@@ -0,0 +1,127 @@
+package graphs
+
+import (
+	".."
+	"../blocks"
+	"sync"
+	"testing"
+)
+
+// Case Structures
+
+func TestAdjust(t *testing.T) {
+	name := "adjust_case"
+	blk, _ := Adjust(0)
+	tests := []struct{ sel, c int }{{0, 6}, {1, 4}, {7, -5}}
+	for _, test := range tests {
+		err := blocks.TestBinary(blk, 5, test.sel, test.c, "IN", flow.SELECTOR_NAME, "OUT", name)
+		if err != nil {
+			t.Error(err.Info)
+		}
+	}
+}
+func TestStepCase(t *testing.T) {
+	name := "step_case"
+	blk, _ := Step(0)
+	for sel, c := range map[bool]int{true: 6, false: 4} {
+		err := blocks.TestBinary(blk, 5, sel, c, "IN", flow.SELECTOR_NAME, "OUT", name)
+		if err != nil {
+			t.Error(err.Info)
+		}
+	}
+}
+func TestNudge(t *testing.T) {
+	name := "nudge_case"
+	blk, _ := Nudge(0)
+	for sel, c := range map[string]int{"up": 6, "down": 4} {
+		err := blocks.TestBinary(blk, 5, sel, c, "IN", flow.SELECTOR_NAME, "OUT", name)
+		if err != nil {
+			t.Error(err.Info)
+		}
+	}
+
+	// No default branch
+	_, err := blocks.RunBlock(blk, flow.ParamValues{"IN": 5, flow.SELECTOR_NAME: "left"})
+	if err == nil || err.Class != flow.VALUE_ERROR {
+		t.Error("Selector without a branch accepted.")
+	}
+
+	// Falls back to a default branch once there is one
+	inv, _ := blocks.InvInt(0)
+	blk.SetDefault(inv)
+	err = blocks.TestBinary(blk, 5, "left", -5, "IN", flow.SELECTOR_NAME, "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
+func TestCaseBranches(t *testing.T) {
+	ins := flow.ParamTypes{"IN": flow.Int}
+	outs := flow.ParamTypes{"OUT": flow.Int}
+	c, _ := flow.NewCase("checked_case", flow.Int, ins, outs)
+	inc, _ := blocks.Inc(0)
+	sqrt, _ := blocks.Sqrt(0)
+	cat, _ := blocks.Concat(0)
+	if err := c.AddCase(0, sqrt); err == nil || err.Class != flow.TYPE_ERROR {
+		t.Error("Branch with other outputs accepted.")
+	}
+	if err := c.AddCase(0, cat); err == nil || err.Class != flow.TYPE_ERROR {
+		t.Error("Branch with other inputs accepted.")
+	}
+	if err := c.SetDefault(sqrt); err == nil || err.Class != flow.TYPE_ERROR {
+		t.Error("Default branch with other outputs accepted.")
+	}
+	if err := c.AddCase("0", inc); err == nil || err.Class != flow.TYPE_ERROR {
+		t.Error("Key of the wrong type accepted.")
+	}
+	if err := c.AddCase(0, inc); err != nil {
+		t.Error(err.Info)
+	}
+	if err := c.AddCase(0, inc); err == nil || err.Class != flow.ALREADY_EXISTS_ERROR {
+		t.Error("Second branch for a key accepted.")
+	}
+	if _, err := flow.NewCase("float_case", flow.Float, ins, outs); err == nil || err.Class != flow.TYPE_ERROR {
+		t.Error("Float selector accepted.")
+	}
+}
+
+// Keeps the names of the blocks which start.
+type starts struct {
+	flow.BaseObserver
+	lock  sync.Mutex
+	names map[string]int
+}
+
+func (o *starts) OnNodeStart(addr flow.Address, ins flow.ParamValues) {
+	o.lock.Lock()
+	o.names[addr.Name] += 1
+	o.lock.Unlock()
+}
+
+func TestCaseRunsOneBranch(t *testing.T) {
+	name := "adjusted"
+	tests := []struct {
+		sel, c int
+		branch string
+	}{{0, 6, "increment"}, {1, 4, "decrement"}, {7, -5, "invert_int"}}
+	for _, test := range tests {
+		c, c_addr := Adjust(0)
+		ins := flow.ParamTypes{"IN": flow.Int, flow.SELECTOR_NAME: flow.Int}
+		outs := flow.ParamTypes{"OUT": flow.Int}
+		graph, _ := flow.NewGraph(name, ins, outs)
+		graph.AddNode(c, c_addr)
+		graph.LinkIn("IN", "IN", c_addr)
+		graph.LinkIn(flow.SELECTOR_NAME, flow.SELECTOR_NAME, c_addr)
+		graph.LinkOut(c_addr, "OUT", "OUT")
+		obs := &starts{names: make(map[string]int)}
+		graph.AddObserver(obs)
+		err := blocks.TestBinary(graph, 5, test.sel, test.c, "IN", flow.SELECTOR_NAME, "OUT", name)
+		if err != nil {
+			t.Error(err.Info)
+		}
+
+		// The case node, then only the chosen branch
+		if len(obs.names) != 2 || obs.names["adjust_case"] != 1 || obs.names[test.branch] != 1 {
+			t.Error(test.sel, " started ", obs.names)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package graphs
+
+import (
+	".."
+	"../blocks"
+)
+
+// Adds length to IN through a chain of length increment nodes.
+func Chain(id flow.InstanceID, length int) (*flow.Graph, flow.Address) {
+	ins := flow.ParamTypes{"IN": flow.Int}
+	outs := flow.ParamTypes{"OUT": flow.Int}
+	graph, _ := flow.NewGraph("inc_chain", ins, outs)
+	addr := flow.Address{"inc_chain", id}
+
+	var last flow.Address
+	for i := 0; i < length; i++ {
+		inc, inc_addr := blocks.Inc(flow.InstanceID(i))
+		graph.AddNode(inc, inc_addr)
+		if i == 0 {
+			graph.LinkIn("IN", "IN", inc_addr)
+		} else {
+			graph.AddEdge(last, "OUT", inc_addr, "IN")
+		}
+		last = inc_addr
+	}
+	graph.LinkOut(last, "OUT", "OUT")
+
+	return graph, addr
+}
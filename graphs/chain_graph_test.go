@@ -0,0 +1,106 @@
+package graphs
+
+import (
+	".."
+	"../blocks"
+	"testing"
+)
+
+// Schedulers
+
+func TestChain(t *testing.T) {
+	name := "inc_chain"
+	blk, _ := Chain(0, 100)
+	err := blocks.TestUnary(blk, 1, 101, "IN", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
+func TestChainPool(t *testing.T) {
+	name := "inc_chain"
+	blk, _ := Chain(0, 100)
+	pool := flow.NewWorkerPool(2)
+	defer pool.Close()
+	blk.SetScheduler(pool)
+	for i := 0; i < 3; i++ {
+		err := blocks.TestUnary(blk, i, i+100, "IN", "OUT", name)
+		if err != nil {
+			t.Error(err.Info)
+		}
+	}
+}
+func TestPowerPool(t *testing.T) {
+	name := "power_loop"
+	blk, _ := Power(0)
+	blk.SetScheduler(flow.NewWorkerPool(1))
+	err := blocks.TestBinary(blk, 2.0, 10, 1024.0, "X", "N", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
+func TestNestedPool(t *testing.T) {
+	name := "nested_chain"
+	ins := flow.ParamTypes{"IN": flow.Int}
+	outs := flow.ParamTypes{"OUT": flow.Int}
+	graph, _ := flow.NewGraph(name, ins, outs)
+	first, first_addr := Chain(0, 10)
+	second, second_addr := Chain(1, 10)
+	graph.AddNode(first, first_addr)
+	graph.AddNode(second, second_addr)
+	graph.LinkIn("IN", "IN", first_addr)
+	graph.AddEdge(first_addr, "OUT", second_addr, "IN")
+	graph.LinkOut(second_addr, "OUT", "OUT")
+
+	// One worker must be enough, waiting graphs run the nodes nested inside them
+	graph.SetMaxConcurrency(1)
+	err := blocks.TestUnary(graph, 0, 20, "IN", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
+func BenchmarkChain(b *testing.B) {
+	name := "inc_chain"
+	blk, _ := Chain(0, 100)
+	for i := 0; i < b.N; i++ {
+		err := blocks.TestUnary(blk, 0, 100, "IN", "OUT", name)
+		if err != nil {
+			b.Error(err.Info)
+		}
+	}
+}
+func BenchmarkChainPool(b *testing.B) {
+	name := "inc_chain"
+	blk, _ := Chain(0, 100)
+	pool := flow.NewWorkerPool(4)
+	defer pool.Close()
+	blk.SetScheduler(pool)
+	for i := 0; i < b.N; i++ {
+		err := blocks.TestUnary(blk, 0, 100, "IN", "OUT", name)
+		if err != nil {
+			b.Error(err.Info)
+		}
+	}
+}
+func BenchmarkPower(b *testing.B) {
+	name := "power_loop"
+	blk, _ := Power(0)
+	for i := 0; i < b.N; i++ {
+		err := blocks.TestBinary(blk, 2.0, 10, 1024.0, "X", "N", "OUT", name)
+		if err != nil {
+			b.Error(err.Info)
+		}
+	}
+}
+func BenchmarkPowerPool(b *testing.B) {
+	name := "power_loop"
+	blk, _ := Power(0)
+	pool := flow.NewWorkerPool(4)
+	defer pool.Close()
+	blk.SetScheduler(pool)
+	for i := 0; i < b.N; i++ {
+		err := blocks.TestBinary(blk, 2.0, 10, 1024.0, "X", "N", "OUT", name)
+		if err != nil {
+			b.Error(err.Info)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package graphs
+
+import (
+	".."
+	"../blocks"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Checkpoints
+
+// Resumes blk from cp with ins and returns its outputs.
+func resume(blk *flow.Loop, cp *flow.Checkpoint, ins flow.ParamValues) (flow.ParamValues, *flow.FlowError) {
+	f_out := make(chan flow.ParamValues)
+	f_stop := make(chan bool)
+	f_err := make(chan *flow.FlowError)
+	go blk.Resume(cp, ins, f_out, f_stop, f_err, 0)
+	select {
+	case out := <-f_out:
+		return out, nil
+	case err := <-f_err:
+		return nil, err
+	}
+}
+
+func TestCheckpointSquares(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "squares.ckpt")
+	blk, _ := Squares(0)
+	blk.SetCheckpoint(path, 2)
+	in := flow.ParamValues{"X": 3.0, "N": 5}
+	if _, err := blocks.RunBlock(blk, in); err != nil {
+		t.Fatal(err.Info)
+	}
+
+	// The last checkpoint was saved after the fourth iteration
+	cp, err := flow.ReadCheckpoint(path)
+	switch {
+	case err != nil:
+		t.Fatal(err)
+	case cp.Loop != "squares_loop" || cp.Iteration != 4 || cp.Done:
+		t.Error("Wrong checkpoint: ", cp)
+	case !reflect.DeepEqual(cp.Outputs["Squares"], []float64{0, 1, 4, 9}):
+		t.Error("Wrong collected outputs: ", cp.Outputs)
+	}
+
+	// Resuming runs only the fifth iteration
+	blk.SetCheckpoint("", 0)
+	out, flow_err := resume(blk, cp, in)
+	switch {
+	case flow_err != nil:
+		t.Error(flow_err.Info)
+	case !reflect.DeepEqual(out["Squares"], []float64{0, 1, 4, 9, 16}):
+		t.Error("Indexed output is wrong: ", out["Squares"])
+	case !reflect.DeepEqual(out["Large"], []float64{4, 9, 16}):
+		t.Error("Conditional output is wrong: ", out["Large"])
+	case out[flow.COUNT_NAME] != 5:
+		t.Error("Wrong count: ", out[flow.COUNT_NAME])
+	}
+}
+
+func TestCheckpointRegisters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fibonacci.ckpt")
+	blk, _ := Fibonacci(0)
+	blk.SetCheckpoint(path, 3)
+	if _, err := blocks.RunBlock(blk, flow.ParamValues{"N": 4}); err != nil {
+		t.Fatal(err.Info)
+	}
+	cp, err := flow.ReadCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.Iteration != 3 || cp.Registers["Last"] != 3.0 || cp.Registers["Before"] != 2.0 {
+		t.Error("Wrong checkpoint: ", cp)
+	}
+
+	// Both levels of the stacked register carry on
+	out, flow_err := resume(blk, cp, flow.ParamValues{"N": 5})
+	if flow_err != nil || out["OUT"] != 8.0 {
+		t.Error("Wrong result: ", out, flow_err)
+	}
+
+	// Checkpoints only resume the loop which saved them
+	other, _ := Counter(0)
+	if _, flow_err := resume(other, cp, flow.ParamValues{"N": 5}); flow_err == nil || flow_err.Class != flow.VALUE_ERROR {
+		t.Error("Resumed another loop: ", flow_err)
+	}
+}
+
+func TestCheckpointError(t *testing.T) {
+	blk, _ := Counter(0)
+	blk.SetCheckpoint(filepath.Join(t.TempDir(), "missing", "counter.ckpt"), 1)
+	if _, err := blocks.RunBlock(blk, flow.ParamValues{"N": 3}); err == nil || err.Class != flow.IO_ERROR {
+		t.Error("Wrong error: ", err)
+	}
+}
@@ -0,0 +1,127 @@
+package graphs
+
+import (
+	".."
+	"../blocks"
+	"testing"
+)
+
+// Debugging
+
+func TestBreakpoint(t *testing.T) {
+	blk, _ := Nand(0)
+	d := flow.NewDebugger()
+	blk.AddObserver(d)
+	not_addr := flow.Address{"invert_bool", 0}
+	d.SetBreakpoint(not_addr)
+	probe := d.AddProbe(flow.Address{"logical_and", 0}, "OUT", not_addr, "IN")
+
+	run := d.Start(blk, flow.ParamValues{"A": true, "B": true}, 0)
+	p, ok := run.Wait()
+	switch {
+	case !ok:
+		t.Fatal("Run ended without pausing.")
+	case p.Addr != not_addr || p.Inputs["IN"] != true:
+		t.Error("Wrong pause: ", p.Addr, p.Inputs)
+	}
+	if vals := probe.Values(); len(vals) != 1 || vals[0] != true {
+		t.Error("Wrong probe values: ", vals)
+	}
+
+	run.Continue()
+	if _, ok := run.Wait(); ok {
+		t.Error("Paused again.")
+	}
+	out, err := run.Result()
+	if err != nil || out["OUT"] != false {
+		t.Error("Wrong result: ", out, err)
+	}
+}
+func TestStep(t *testing.T) {
+	blk, _ := Chain(0, 3)
+	d := flow.NewDebugger()
+	blk.AddObserver(d)
+	d.SetBreakpoint(flow.Address{"increment", 0})
+
+	// Every node of the chain pauses once stepping
+	run := d.Start(blk, flow.ParamValues{"IN": 0}, 0)
+	for i := 0; i < 3; i++ {
+		p, ok := run.Wait()
+		if !ok {
+			t.Fatal("Run ended at step ", i)
+		}
+		if p.Addr.ID != flow.InstanceID(i) || p.Inputs["IN"] != i {
+			t.Error("Wrong pause: ", p.Addr, p.Inputs)
+		}
+		run.Step()
+	}
+	out, err := run.Result()
+	if err != nil || out["OUT"] != 3 {
+		t.Error("Wrong result: ", out, err)
+	}
+}
+func TestDebugStop(t *testing.T) {
+	blk, _ := Chain(0, 3)
+	d := flow.NewDebugger()
+	blk.AddObserver(d)
+	d.SetBreakpoint(flow.Address{"increment", 1})
+
+	run := d.Start(blk, flow.ParamValues{"IN": 0}, 0)
+	if _, ok := run.Wait(); !ok {
+		t.Fatal("Run ended without pausing.")
+	}
+	run.Stop()
+	out, err := run.Result()
+	if out != nil || err != nil {
+		t.Error("Stopped run returned: ", out, err)
+	}
+}
+func TestDebugConcurrent(t *testing.T) {
+	d := flow.NewDebugger()
+	not_addr := flow.Address{"invert_bool", 0}
+	d.SetBreakpoint(not_addr)
+	ins := flow.ParamValues{"A": true, "B": true}
+	blk1, _ := Nand(0)
+	blk2, _ := Nand(1)
+	blk3, _ := Nand(2)
+	blk1.AddObserver(d)
+	blk2.AddObserver(d)
+	blk3.AddObserver(d)
+
+	run1 := d.Start(blk1, ins, 0)
+	if _, ok := run1.Wait(); !ok {
+		t.Fatal("Run ended without pausing.")
+	}
+
+	// Runs not started by the debugger do not pause
+	if out, err := blocks.RunBlock(blk2, ins); err != nil || out["OUT"] != false {
+		t.Error("Wrong result: ", out, err)
+	}
+
+	// Each run pauses and resumes on its own
+	run2 := d.Start(blk3, ins, 0)
+	if _, ok := run2.Wait(); !ok {
+		t.Fatal("Second run ended without pausing.")
+	}
+	run1.Step()
+	if out, err := run1.Result(); err != nil || out["OUT"] != false {
+		t.Error("Wrong result: ", out, err)
+	}
+	run2.Continue()
+	if out, err := run2.Result(); err != nil || out["OUT"] != false {
+		t.Error("Wrong result: ", out, err)
+	}
+}
+func TestDebugStopReturning(t *testing.T) {
+	d := flow.NewDebugger()
+
+	// Stopped at every point of the run, including while it returns its outputs
+	for i := 0; i < 100; i++ {
+		blk, _ := Chain(0, 3)
+		run := d.Start(blk, flow.ParamValues{"IN": 0}, 0)
+		run.Stop()
+		if out, err := run.Result(); err != nil || (out != nil && out["OUT"] != 3) {
+			t.Error("Wrong result: ", out, err)
+		}
+	}
+}
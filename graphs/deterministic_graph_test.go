@@ -0,0 +1,81 @@
+package graphs
+
+import (
+	".."
+	"../blocks"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// Deterministic Mode
+
+func TestDeterministicOrder(t *testing.T) {
+	ins := flow.ParamTypes{"IN": flow.Int}
+	outs := flow.ParamTypes{"OUT": flow.Int}
+	g, _ := flow.NewGraph("fan", ins, outs)
+	order, lock := []string{}, &sync.Mutex{}
+	names := []string{"e", "b", "d", "a", "c"}
+	for _, name := range names {
+		blk, addr := recorder(name, 0, &order, lock)
+		g.AddNode(blk, addr)
+		g.LinkIn("IN", "IN", addr)
+	}
+	g.LinkOut(flow.Address{"c", 0}, "OUT", "OUT")
+	g.SetDeterministic(0)
+
+	// Nodes run in the order they were linked, even those the output does not wait for
+	for i := 0; i < 5; i++ {
+		order = order[:0]
+		_, err := blocks.RunBlock(g, flow.ParamValues{"IN": i})
+		if err != nil {
+			t.Fatal(err.Info)
+		}
+		if !reflect.DeepEqual(order, names) {
+			t.Error("Nodes ran out of order: ", order)
+		}
+	}
+}
+func TestDeterministicSeed(t *testing.T) {
+	ins := flow.ParamTypes{"N": flow.Int}
+	outs := flow.ParamTypes{"X": flow.Float, "I": flow.Int}
+	g, _ := flow.NewGraph("dice", ins, outs)
+	x, x_addr := blocks.Random(0)
+	i, i_addr := blocks.RandomInt(0)
+	g.AddNode(x, x_addr)
+	g.AddNode(i, i_addr)
+	g.LinkIn("N", "N", i_addr)
+	g.LinkOut(x_addr, "OUT", "X")
+	g.LinkOut(i_addr, "OUT", "I")
+
+	// Runs the graph three times after seeding it
+	runs := func(seed int64) string {
+		g.SetDeterministic(seed)
+		res := ""
+		for n := 0; n < 3; n++ {
+			out, err := blocks.RunBlock(g, flow.ParamValues{"N": 1000})
+			if err != nil {
+				t.Fatal(err.Info)
+			}
+			res += fmt.Sprint(out["X"], out["I"], ";")
+		}
+		return res
+	}
+	first := runs(42)
+	if again := runs(42); again != first {
+		t.Error("Same seed gave ", first, " then ", again)
+	}
+	if other := runs(43); other == first {
+		t.Error("Different seeds gave the same results.")
+	}
+}
+func TestDeterministicLoop(t *testing.T) {
+	name := "power_loop"
+	blk, _ := Power(0)
+	blk.SetDeterministic(0)
+	err := blocks.TestBinary(blk, 2.0, 8, 256.0, "X", "N", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
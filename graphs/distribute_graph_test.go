@@ -0,0 +1,214 @@
+package graphs
+
+import (
+	".."
+	"../blocks"
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Distributed execution
+
+// Registers the blocks the tests place on workers, counting how many are built.
+func newWorker(built *int32) *flow.Worker {
+	w := flow.NewWorker()
+	w.Register("increment", func(id flow.InstanceID) flow.FunctionBlock {
+		if built != nil {
+			atomic.AddInt32(built, 1)
+		}
+		blk, _ := blocks.Inc(id)
+		return blk
+	})
+	w.Register("inc_chain", func(id flow.InstanceID) flow.FunctionBlock {
+		blk, _ := Chain(id, 3)
+		return blk
+	})
+	return w
+}
+
+// Serves a worker on a unix socket in a temporary directory and returns the path.
+func serveWorker(t *testing.T, w *flow.Worker) string {
+	path := filepath.Join(t.TempDir(), "worker.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go w.Serve(l)
+	return path
+}
+
+// Not a test: serves a worker when the test binary is started as one by TestDistributeProcess.
+func TestWorkerProcess(t *testing.T) {
+	path := os.Getenv("GOFLOW_WORKER_SOCKET")
+	if path == "" {
+		t.Skip("Only runs as a worker process.")
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println("ready")
+	newWorker(nil).Serve(l)
+}
+
+func TestDistribute(t *testing.T) {
+	var built int32
+	path := serveWorker(t, newWorker(&built))
+	cluster := flow.NewCluster()
+	defer cluster.Close()
+	if err := cluster.Connect("w1", "unix", path); err != nil {
+		t.Fatal(err.Info)
+	}
+	if err := cluster.Connect("w1", "unix", path); err == nil || err.Class != flow.ALREADY_EXISTS_ERROR {
+		t.Error("Connected the same name twice.")
+	}
+
+	blk, _ := Chain(0, 5)
+	blk.SetCluster(cluster)
+	blk.Place(flow.Address{"increment", 1}, "w1")
+	blk.Place(flow.Address{"increment", 3}, "w1")
+	if err := blk.Place(flow.Address{"increment", 9}, "w1"); err == nil || err.Class != flow.DNE_ERROR {
+		t.Error("Placed a node which does not exist.")
+	}
+	for i := 0; i < 3; i++ {
+		if err := blocks.TestUnary(blk, i, i+5, "IN", "OUT", "inc_chain"); err != nil {
+			t.Error(err.Info)
+		}
+	}
+	if n := atomic.LoadInt32(&built); n != 6 {
+		t.Error("Worker built ", n, " blocks, expected 6.")
+	}
+
+	// A nested graph runs whole on the worker
+	outer, _ := flow.NewGraph("outer", flow.ParamTypes{"IN": flow.Int}, flow.ParamTypes{"OUT": flow.Int})
+	inner, inner_addr := Chain(0, 3)
+	outer.AddNode(inner, inner_addr)
+	outer.LinkIn("IN", "IN", inner_addr)
+	outer.LinkOut(inner_addr, "OUT", "OUT")
+	outer.SetCluster(cluster)
+	outer.Place(inner_addr, "w1")
+	if err := blocks.TestUnary(outer, 1, 4, "IN", "OUT", "outer"); err != nil {
+		t.Error(err.Info)
+	}
+}
+
+func TestDistributeErrors(t *testing.T) {
+	path := serveWorker(t, flow.NewWorker())
+	cluster := flow.NewCluster()
+	defer cluster.Close()
+	if err := cluster.Connect("empty", "unix", path); err != nil {
+		t.Fatal(err.Info)
+	}
+	if err := cluster.Connect("none", "unix", path+".missing"); err == nil || err.Class != flow.REMOTE_ERROR {
+		t.Error("Connected to a worker which does not exist.")
+	}
+
+	// Blocks the worker does not know
+	blk, _ := Chain(0, 2)
+	blk.SetCluster(cluster)
+	blk.Place(flow.Address{"increment", 1}, "empty")
+	_, err := runOnce(blk, 1)
+	if err == nil || err.Class != flow.DNE_ERROR || err.Addr != (flow.Address{"increment", 1}) {
+		t.Error("Wrong error: ", err)
+	}
+
+	// Workers which were never connected
+	blk.Place(flow.Address{"increment", 1}, "none")
+	if _, err := runOnce(blk, 1); err == nil || err.Class != flow.DNE_ERROR {
+		t.Error("Wrong error: ", err)
+	}
+
+	// Placing a node back here
+	blk.Place(flow.Address{"increment", 1}, "")
+	if out, err := runOnce(blk, 1); err != nil || out["OUT"] != 3 {
+		t.Error("Wrong result: ", out, err)
+	}
+}
+
+func TestDistributeStop(t *testing.T) {
+	stopped := make(chan bool, 1)
+	w := flow.NewWorker()
+	w.Register("hang", func(id flow.InstanceID) flow.FunctionBlock {
+		return flow.NewPrimitive("hang", func(in flow.ParamValues, out chan flow.ParamValues, stop chan bool, err chan *flow.Error) {
+			<-stop
+			stopped <- true
+		}, flow.ParamTypes{"IN": flow.Int}, flow.ParamTypes{"OUT": flow.Int})
+	})
+	path := serveWorker(t, w)
+	cluster := flow.NewCluster()
+	defer cluster.Close()
+	cluster.Connect("w1", "unix", path)
+
+	graph, _ := flow.NewGraph("hanging", flow.ParamTypes{"IN": flow.Int}, flow.ParamTypes{"OUT": flow.Int})
+	hang := flow.Address{"hang", 0}
+	graph.AddNode(flow.NewPrimitive("hang", nil, flow.ParamTypes{"IN": flow.Int}, flow.ParamTypes{"OUT": flow.Int}), hang)
+	graph.LinkIn("IN", "IN", hang)
+	graph.LinkOut(hang, "OUT", "OUT")
+	graph.SetCluster(cluster)
+	graph.Place(hang, "w1")
+
+	_, stop, _ := flow.BlockRun(graph, flow.ParamValues{"IN": 1}, 0)
+	time.Sleep(10 * time.Millisecond)
+	stop <- true
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Error("Stop did not reach the worker.")
+	}
+}
+
+func TestDistributeProcess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "worker.sock")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestWorkerProcess$")
+	cmd.Env = append(os.Environ(), "GOFLOW_WORKER_SOCKET="+path)
+	stdout, _ := cmd.StdoutPipe()
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Process.Kill()
+	if line, err := bufio.NewReader(stdout).ReadString('\n'); err != nil || line != "ready\n" {
+		t.Fatal("Worker did not start: ", line, err)
+	}
+
+	cluster := flow.NewCluster()
+	defer cluster.Close()
+	if err := cluster.Connect("proc", "unix", path); err != nil {
+		t.Fatal(err.Info)
+	}
+	blk, _ := Chain(0, 4)
+	blk.SetCluster(cluster)
+	for i := 0; i < 4; i++ {
+		blk.Place(flow.Address{"increment", flow.InstanceID(i)}, "proc")
+	}
+	if err := blocks.TestUnary(blk, 1, 5, "IN", "OUT", "inc_chain"); err != nil {
+		t.Error(err.Info)
+	}
+
+	// The graph fails once the worker is gone
+	cmd.Process.Kill()
+	cmd.Wait()
+	if _, err := runOnce(blk, 1); err == nil || err.Class != flow.REMOTE_ERROR {
+		t.Error("Wrong error: ", err)
+	}
+}
+
+// Runs blk with IN set to in and returns its outputs, or its error.
+func runOnce(blk flow.FunctionBlock, in int) (flow.ParamValues, *flow.FlowError) {
+	out, _, err := flow.BlockRun(blk, flow.ParamValues{"IN": in}, 0)
+	select {
+	case vals := <-out:
+		return vals, nil
+	case flow_err := <-err:
+		return nil, flow_err
+	case <-time.After(5 * time.Second):
+		return nil, flow.NewFlowError(flow.NOT_READY_ERROR, "Timed out.", flow.Address{})
+	}
+}
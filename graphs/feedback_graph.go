@@ -0,0 +1,26 @@
+package graphs
+
+import (
+	".."
+	"../blocks"
+)
+
+// Outputs the running total of every IN passed to it across runs.
+func Accumulate(id flow.InstanceID) (*flow.Graph, flow.Address) {
+	// Create Graph
+	ins := flow.ParamTypes{"IN": flow.Float}
+	outs := flow.ParamTypes{"OUT": flow.Float}
+	graph, _ := flow.NewGraph("accumulate", ins, outs)
+	addr := flow.Address{"accumulate", id}
+
+	// Create Blocks
+	sum, sum_addr := blocks.PlusFloat(0)
+	graph.AddNode(sum, sum_addr)
+
+	// Add Edges
+	graph.LinkIn("IN", "A", sum_addr)
+	graph.AddFeedbackEdge(sum_addr, "OUT", sum_addr, "B", 0.0) // Feed the total back into itself
+	graph.LinkOut(sum_addr, "OUT", "OUT")
+
+	return graph, addr
+}
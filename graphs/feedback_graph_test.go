@@ -0,0 +1,24 @@
+package graphs
+
+import (
+	"../blocks"
+	"testing"
+)
+
+// Feedback
+
+func TestAccumulate(t *testing.T) {
+	name := "accumulate"
+	blk, _ := Accumulate(0)
+	totals := []float64{1, 3, 6}
+	for i, c := range totals {
+		err := blocks.TestUnary(blk, float64(i+1), c, "IN", "OUT", name)
+		if err != nil {
+			t.Error(err.Info)
+		}
+	}
+	blk.ResetFeedback()
+	if err := blocks.TestUnary(blk, 1.0, 1.0, "IN", "OUT", name); err != nil {
+		t.Error(err.Info)
+	}
+}
@@ -0,0 +1,61 @@
+package graphs
+
+import (
+	".."
+	"../blocks"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// Logging
+
+func TestLogger(t *testing.T) {
+	name := "power_loop"
+	blk, _ := Power(0)
+	var buf bytes.Buffer
+	blk.SetLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	err := blocks.TestBinary(blk, 2.0, 2, 4.0, "X", "N", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+
+	// Every record is JSON, with the fields of where it was logged
+	nodes, iterations := 0, 0
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var rec map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatal(err)
+		}
+		if node, ok := rec["node"].(map[string]interface{}); ok && node["name"] == "numeric_multiply_float" {
+			nodes += 1
+		}
+		if _, ok := rec["iteration"]; ok {
+			iterations += 1
+		}
+	}
+	if nodes == 0 || iterations == 0 {
+		t.Error("Missing fields: ", buf.String())
+	}
+}
+func TestLoggerLevel(t *testing.T) {
+	name := "logical_nand"
+	blk, _ := Nand(0)
+	var buf bytes.Buffer
+	blk.SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	err := blocks.TestBinary(blk, true, true, false, "A", "B", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+	if buf.Len() != 0 {
+		t.Error("Debug records logged at the info level: ", buf.String())
+	}
+}
+func TestCreateLogger(t *testing.T) {
+	logger := flow.CreateLogger("/does/not/exist/flow.log", "[INFO]")
+	if logger == nil {
+		t.Error("No logger returned.")
+	}
+}
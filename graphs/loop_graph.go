@@ -0,0 +1,183 @@
+package graphs
+
+import (
+	".."
+	"../blocks"
+)
+
+// Raises X to the power of N by multiplying X into a register N times.
+func Power(id flow.InstanceID) (*flow.Loop, flow.Address) {
+	// Create Multiplication Block
+	ins := flow.ParamTypes{"X": flow.Float, "Total": flow.Float}
+	outs := flow.ParamTypes{"OUT": flow.Float}
+	g, _ := flow.NewGraph("power_step", ins, outs)
+
+	mult, mult_addr := blocks.MultFloat(0)
+	g.AddNode(mult, mult_addr)
+	g.LinkIn("X", "A", mult_addr)
+	g.LinkIn("Total", "B", mult_addr)
+	g.LinkOut(mult_addr, "OUT", "OUT")
+
+	// Create Loop
+	ins = flow.ParamTypes{"X": flow.Float}
+	outs = flow.ParamTypes{"OUT": flow.Float}
+	loop, _ := flow.NewForLoop("power_loop", ins, outs, g)
+	loop_addr := flow.Address{"power_loop", id}
+
+	loop.LinkIn("X", "X")
+	loop.AddDefaultRegister("OUT", "Total", 1.0)
+	loop.LinkOut("OUT", "OUT")
+
+	return loop, loop_addr
+}
+
+// Halves X while it is greater than one.
+func Halve(id flow.InstanceID) (*flow.Loop, flow.Address) {
+	// Create Division Block
+	ins := flow.ParamTypes{"X": flow.Float}
+	outs := flow.ParamTypes{"OUT": flow.Float}
+	g, _ := flow.NewGraph("halve_step", ins, outs)
+
+	div, div_addr := blocks.DivFloat(0)
+	g.AddNode(div, div_addr)
+	g.LinkIn("X", "A", div_addr)
+	g.AddConstant(2.0, div_addr, "B")
+	g.LinkOut(div_addr, "OUT", "OUT")
+
+	// Create Condition
+	outs = flow.ParamTypes{"OUT": flow.Bool}
+	cnd, _ := flow.NewGraph("halve_condition", ins, outs)
+
+	gt, gt_addr := blocks.Greater(0)
+	cnd.AddNode(gt, gt_addr)
+	cnd.LinkIn("X", "A", gt_addr)
+	cnd.AddConstant(1.0, gt_addr, "B")
+	cnd.LinkOut(gt_addr, "OUT", "OUT")
+
+	// Create Loop
+	outs = flow.ParamTypes{"OUT": flow.Float}
+	loop, _ := flow.NewWhileLoop("halve_loop", ins, outs, g, cnd)
+	loop_addr := flow.Address{"halve_loop", id}
+
+	loop.LinkIn("X", "X")
+	loop.AddRegister("OUT", "X", flow.Float)
+	loop.LinkCondition("X", "X")
+	loop.LinkOut("OUT", "OUT")
+
+	return loop, loop_addr
+}
+
+// Computes the number N places after the start of the Fibonacci sequence 1, 1, 2, 3, 5...
+// using a register which remembers the last two iterations.
+func Fibonacci(id flow.InstanceID) (*flow.Loop, flow.Address) {
+	// Create Addition Block
+	ins := flow.ParamTypes{"Last": flow.Float, "Before": flow.Float}
+	outs := flow.ParamTypes{"OUT": flow.Float}
+	g, _ := flow.NewGraph("fibonacci_step", ins, outs)
+
+	sum, sum_addr := blocks.PlusFloat(0)
+	g.AddNode(sum, sum_addr)
+	g.LinkIn("Last", "A", sum_addr)
+	g.LinkIn("Before", "B", sum_addr)
+	g.LinkOut(sum_addr, "OUT", "OUT")
+
+	// Create Loop
+	ins = flow.ParamTypes{}
+	loop, _ := flow.NewForLoop("fibonacci_loop", ins, outs, g)
+	loop_addr := flow.Address{"fibonacci_loop", id}
+
+	loop.AddStackedRegister("OUT", []string{"Last", "Before"}, []interface{}{1.0, 0.0})
+	loop.LinkOut("OUT", "OUT")
+
+	return loop, loop_addr
+}
+
+// Collects the squares of the first N integers, and separately the ones greater than X.
+func Squares(id flow.InstanceID) (*flow.Loop, flow.Address) {
+	// Create Square Block
+	ins := flow.ParamTypes{"I": flow.Int, "X": flow.Float}
+	outs := flow.ParamTypes{"OUT": flow.Float, "Large": flow.Bool}
+	g, _ := flow.NewGraph("square_step", ins, outs)
+
+	toflt, toflt_addr := blocks.InttoFloat(0)
+	mult, mult_addr := blocks.MultFloat(0)
+	gt, gt_addr := blocks.Greater(0)
+	g.AddNode(toflt, toflt_addr)
+	g.AddNode(mult, mult_addr)
+	g.AddNode(gt, gt_addr)
+	g.LinkIn("I", "IN", toflt_addr)
+	g.LinkIn("X", "B", gt_addr)
+	g.AddEdge(toflt_addr, "OUT", mult_addr, "A")
+	g.AddEdge(toflt_addr, "OUT", mult_addr, "B")
+	g.AddEdge(mult_addr, "OUT", gt_addr, "A")
+	g.LinkOut(mult_addr, "OUT", "OUT")
+	g.LinkOut(gt_addr, "OUT", "Large")
+
+	// Create Loop
+	ins = flow.ParamTypes{"X": flow.Float}
+	outs = flow.ParamTypes{"Squares": flow.NumArray, "Large": flow.NumArray}
+	loop, _ := flow.NewForLoop("squares_loop", ins, outs, g)
+	loop_addr := flow.Address{"squares_loop", id}
+
+	loop.LinkIn(flow.INDEX_NAME, "I")
+	loop.LinkIn("X", "X")
+	loop.LinkOutMode("OUT", "Squares", flow.INDEXING)
+	loop.LinkOutConditional("OUT", "Large", "Large")
+
+	return loop, loop_addr
+}
+
+// Creates the body of Tally, which returns I copies of I as Copies,
+// and I as OUT with whether it is greater than X as Over.
+func tallyStep() *flow.Graph {
+	ins := flow.ParamTypes{"I": flow.Int, "X": flow.Float}
+	outs := flow.ParamTypes{"Copies": flow.NumArray, "OUT": flow.Float, "Over": flow.Bool}
+	g, _ := flow.NewGraph("tally_step", ins, outs)
+
+	toflt, toflt_addr := blocks.InttoFloat(0)
+	fill, fill_addr := blocks.Fill(0)
+	gt, gt_addr := blocks.Greater(0)
+	g.AddNode(toflt, toflt_addr)
+	g.AddNode(fill, fill_addr)
+	g.AddNode(gt, gt_addr)
+	g.LinkIn("I", "IN", toflt_addr)
+	g.LinkIn("I", "N", fill_addr)
+	g.LinkIn("X", "B", gt_addr)
+	g.AddEdge(toflt_addr, "OUT", fill_addr, "IN")
+	g.AddEdge(toflt_addr, "OUT", gt_addr, "A")
+	g.LinkOut(fill_addr, "OUT", "Copies")
+	g.LinkOut(toflt_addr, "OUT", "OUT")
+	g.LinkOut(gt_addr, "OUT", "Over")
+	return g
+}
+
+// Joins I copies of each of the first N integers I into All, and collects the ones greater than X into Over.
+func Tally(id flow.InstanceID) (*flow.Loop, flow.Address) {
+	ins := flow.ParamTypes{"X": flow.Float}
+	outs := flow.ParamTypes{"All": flow.NumArray, "Over": flow.NumArray}
+	loop, _ := flow.NewForLoop("tally_loop", ins, outs, tallyStep())
+	loop_addr := flow.Address{"tally_loop", id}
+
+	loop.LinkIn(flow.INDEX_NAME, "I")
+	loop.LinkIn("X", "X")
+	loop.LinkOutMode("Copies", "All", flow.CONCATENATING)
+	loop.LinkOutConditional("OUT", "Over", "Over")
+
+	return loop, loop_addr
+}
+
+// Counts up from zero N times by looping over a single increment block.
+func Counter(id flow.InstanceID) (*flow.Loop, flow.Address) {
+	inc, _ := blocks.Inc(0)
+
+	// Create Loop
+	ins := flow.ParamTypes{}
+	outs := flow.ParamTypes{"OUT": flow.Int}
+	loop, _ := flow.NewForLoop("counter_loop", ins, outs, inc)
+	loop_addr := flow.Address{"counter_loop", id}
+
+	loop.AddDefaultRegister("OUT", "IN", 0)
+	loop.LinkOut("OUT", "OUT")
+
+	return loop, loop_addr
+}
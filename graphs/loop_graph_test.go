@@ -0,0 +1,124 @@
+package graphs
+
+import (
+	".."
+	"../blocks"
+	"reflect"
+	"testing"
+)
+
+// Loops
+
+func TestPower(t *testing.T) {
+	name := "power_loop"
+	blk, _ := Power(0)
+	x, n := 2.0, 3
+	c := 8.0
+	err := blocks.TestBinary(blk, x, n, c, "X", "N", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
+func TestHalve(t *testing.T) {
+	name := "halve_loop"
+	blk, _ := Halve(0)
+	x := 10.0
+	c := 0.625
+	err := blocks.TestUnary(blk, x, c, "X", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
+func TestFibonacci(t *testing.T) {
+	name := "fibonacci_loop"
+	blk, _ := Fibonacci(0)
+	n := 5
+	c := 8.0
+	err := blocks.TestUnary(blk, n, c, "N", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+}
+func TestSquares(t *testing.T) {
+	blk, _ := Squares(0)
+	in := flow.ParamValues{"X": 3.0, "N": 4}
+	out, err := blocks.RunBlock(blk, in)
+	switch {
+	case err != nil:
+		t.Error(err.Info)
+	case !reflect.DeepEqual(out["Squares"], []float64{0, 1, 4, 9}):
+		t.Error("Indexed output is wrong: ", out["Squares"])
+	case !reflect.DeepEqual(out["Large"], []float64{4, 9}):
+		t.Error("Conditional output is wrong: ", out["Large"])
+	}
+}
+func TestTally(t *testing.T) {
+	blk, _ := Tally(0)
+	in := flow.ParamValues{"X": 1.5, "N": 4}
+	out, err := blocks.RunBlock(blk, in)
+	switch {
+	case err != nil:
+		t.Error(err.Info)
+	case !reflect.DeepEqual(out["All"], []float64{1, 2, 2, 3, 3, 3}):
+		t.Error("Concatenated output is wrong: ", out["All"])
+	case !reflect.DeepEqual(out["Over"], []float64{2, 3}):
+		t.Error("Conditional output is wrong: ", out["Over"])
+	}
+
+	// Nothing is kept when the condition is never true
+	out, err = blocks.RunBlock(blk, flow.ParamValues{"X": 10.0, "N": 4})
+	switch {
+	case err != nil:
+		t.Error(err.Info)
+	case !reflect.DeepEqual(out["Over"], []float64{}):
+		t.Error("Conditional output is wrong: ", out["Over"])
+	}
+}
+func TestTunnelModes(t *testing.T) {
+	ins := flow.ParamTypes{"X": flow.Float}
+	outs := flow.ParamTypes{"Last": flow.Float, "All": flow.NumArray}
+	loop, _ := flow.NewForLoop("tally_loop", ins, outs, tallyStep())
+	tests := []struct {
+		err  *flow.Error
+		kind int
+		link string
+	}{
+		{loop.LinkOutMode("Copies", "Last", flow.CONCATENATING), flow.TYPE_ERROR, "Concatenated into a Float"},
+		{loop.LinkOutMode("OUT", "All", flow.CONCATENATING), flow.TYPE_ERROR, "Concatenated a Float"},
+		{loop.LinkOutConditional("Copies", "Over", "All"), flow.TYPE_ERROR, "Collected a NumArray"},
+		{loop.LinkOutConditional("Copies", "OUT", "All"), flow.TYPE_ERROR, "Condition of type Float"},
+		{loop.LinkOutMode("OUT", "All", flow.CONDITIONAL), flow.NOT_READY_ERROR, "Conditional without a condition"},
+		{loop.LinkOutMode("Over", flow.DONE_NAME, flow.INDEXING), flow.VALUE_ERROR, "Indexed DONE"},
+		{loop.LinkOutMode("Over", flow.DONE_NAME, flow.CONCATENATING), flow.VALUE_ERROR, "Concatenated DONE"},
+		{loop.LinkOutConditional("Over", "Over", flow.DONE_NAME), flow.VALUE_ERROR, "Conditional DONE"},
+	}
+	for _, test := range tests {
+		if test.err == nil || test.err.Class != test.kind {
+			t.Error(test.link, " accepted: ", test.err)
+		}
+	}
+}
+func TestCounter(t *testing.T) {
+	blk, _ := Counter(0)
+	in := flow.ParamValues{"N": 3}
+	out, err := blocks.RunBlock(blk, in)
+	switch {
+	case err != nil:
+		t.Error(err.Info)
+	case out["OUT"] != 3:
+		t.Error("Not the right value: ", out["OUT"])
+	case out[flow.COUNT_NAME] != 3:
+		t.Error("Not the right count: ", out[flow.COUNT_NAME])
+	}
+}
+func TestHalveCount(t *testing.T) {
+	blk, _ := Halve(0)
+	in := flow.ParamValues{"X": 10.0}
+	out, err := blocks.RunBlock(blk, in)
+	switch {
+	case err != nil:
+		t.Error(err.Info)
+	case out[flow.COUNT_NAME] != 4:
+		t.Error("Not the right count: ", out[flow.COUNT_NAME])
+	}
+}
@@ -0,0 +1,176 @@
+package graphs
+
+import (
+	".."
+	"../blocks"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Observers
+
+// Counts every event it receives.
+type counter struct {
+	lock       sync.Mutex
+	starts     int
+	finishes   int
+	passed     int
+	iterations int
+	errs       []*flow.FlowError
+}
+
+func (c *counter) OnNodeStart(addr flow.Address, ins flow.ParamValues) {
+	c.lock.Lock()
+	c.starts += 1
+	c.lock.Unlock()
+}
+func (c *counter) OnNodeFinish(addr flow.Address, ins, outs flow.ParamValues, d time.Duration) {
+	c.lock.Lock()
+	c.finishes += 1
+	c.lock.Unlock()
+}
+func (c *counter) OnValuePassed(src, dst flow.ParamAddress, val interface{}) {
+	c.lock.Lock()
+	c.passed += 1
+	c.lock.Unlock()
+}
+func (c *counter) OnLoopIteration(addr flow.Address, i int, ins, outs flow.ParamValues, d time.Duration) {
+	c.lock.Lock()
+	c.iterations += 1
+	c.lock.Unlock()
+}
+func (c *counter) OnError(err *flow.FlowError) {
+	c.lock.Lock()
+	c.errs = append(c.errs, err)
+	c.lock.Unlock()
+}
+
+func TestObserver(t *testing.T) {
+	name := "logical_nand"
+	blk, _ := Nand(0)
+	obs := &counter{}
+	blk.AddObserver(obs)
+	err := blocks.TestBinary(blk, true, false, true, "A", "B", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+
+	// A and B into the and node, and into not, not out of the graph
+	if obs.starts != 2 || obs.finishes != 2 || obs.passed != 4 {
+		t.Error("Wrong events: ", obs.starts, obs.finishes, obs.passed)
+	}
+}
+func TestObserverLoop(t *testing.T) {
+	name := "power_loop"
+	blk, _ := Power(0)
+	obs := &counter{}
+	blk.AddObserver(obs)
+	err := blocks.TestBinary(blk, 3.0, 4, 81.0, "X", "N", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+	if obs.iterations != 4 || obs.starts != 4 {
+		t.Error("Wrong events: ", obs.iterations, obs.starts)
+	}
+}
+func TestObserverError(t *testing.T) {
+	ins := flow.ParamTypes{"IN": flow.Int}
+	outs := flow.ParamTypes{"OUT": flow.Int}
+	c, _ := flow.NewCase("inc_case", flow.Int, ins, outs)
+	inc, _ := blocks.Inc(0)
+	c.AddCase(0, inc)
+
+	ins[flow.SELECTOR_NAME] = flow.Int
+	graph, _ := flow.NewGraph("observed_case", ins, outs)
+	c_addr := flow.Address{"inc_case", 0}
+	graph.AddNode(c, c_addr)
+	graph.LinkIn("IN", "IN", c_addr)
+	graph.LinkIn(flow.SELECTOR_NAME, flow.SELECTOR_NAME, c_addr)
+	graph.LinkOut(c_addr, "OUT", "OUT")
+	obs := &counter{}
+	graph.AddObserver(obs)
+
+	// No branch for 1
+	_, err := blocks.RunBlock(graph, flow.ParamValues{"IN": 1, flow.SELECTOR_NAME: 1})
+	if err == nil || len(obs.errs) != 1 || obs.errs[0] != err {
+		t.Error("Error not observed: ", err, obs.errs)
+	}
+}
+
+// Keeps the inputs of every loop iteration.
+type iterationInputs struct {
+	flow.BaseObserver
+	lock sync.Mutex
+	ins  []flow.ParamValues
+}
+
+func (o *iterationInputs) OnLoopIteration(addr flow.Address, i int, ins, outs flow.ParamValues, d time.Duration) {
+	o.lock.Lock()
+	o.ins = append(o.ins, ins)
+	o.lock.Unlock()
+}
+
+func TestObserverPrimitive(t *testing.T) {
+	name := "counter_loop"
+	blk, _ := Counter(0)
+	obs := &counter{}
+	iters := &iterationInputs{}
+	blk.AddObserver(obs)
+	blk.AddObserver(iters)
+	err := blocks.TestUnary(blk, 3, 3, "N", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+
+	// The loop body is a primitive, not a node
+	if obs.iterations != 3 || obs.starts != 3 || obs.finishes != 3 {
+		t.Error("Wrong events: ", obs.iterations, obs.starts, obs.finishes)
+	}
+
+	// Each iteration keeps the inputs it ran with
+	for i, ins := range iters.ins {
+		if ins["IN"] != i {
+			t.Error("Iteration ", i, " ran with ", ins)
+		}
+	}
+}
+func TestTraceError(t *testing.T) {
+	ins := flow.ParamTypes{"IN": flow.Int}
+	outs := flow.ParamTypes{"OUT": flow.Int}
+	c, _ := flow.NewCase("inc_case", flow.Int, ins, outs)
+	ins[flow.SELECTOR_NAME] = flow.Int
+	graph, _ := flow.NewGraph("traced_case", ins, outs)
+	c_addr := flow.Address{"inc_case", 0}
+	graph.AddNode(c, c_addr)
+	graph.LinkIn("IN", "IN", c_addr)
+	graph.LinkIn(flow.SELECTOR_NAME, flow.SELECTOR_NAME, c_addr)
+	graph.LinkOut(c_addr, "OUT", "OUT")
+	tracer := flow.NewTracer()
+	graph.SetTracer(tracer)
+
+	// The case node fails, since there is no branch
+	_, err := blocks.RunBlock(graph, flow.ParamValues{"IN": 1, flow.SELECTOR_NAME: 1})
+	spans, instants := 0, 0
+	for _, ev := range tracer.Events() {
+		switch {
+		case ev.Kind == flow.NODE_EVENT && ev.Addr == c_addr && ev.Err == err && ev.Outputs == nil:
+			spans += 1
+		case ev.Kind == flow.ERROR_EVENT && ev.Err == err:
+			instants += 1
+		default:
+			t.Error("Wrong event: ", ev)
+		}
+	}
+	if err == nil || spans != 1 || instants != 1 {
+		t.Error("Traced ", spans, " error spans and ", instants, " errors for ", err)
+	}
+
+	// Turned off
+	graph.SetTracer(nil)
+	tracer.Reset()
+	blocks.RunBlock(graph, flow.ParamValues{"IN": 1, flow.SELECTOR_NAME: 1})
+	if events := tracer.Events(); len(events) != 0 {
+		t.Error("Traced while off: ", events)
+	}
+}
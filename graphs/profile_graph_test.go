@@ -0,0 +1,151 @@
+package graphs
+
+import (
+	".."
+	"../blocks"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Profiling
+
+func TestProfileChain(t *testing.T) {
+	blk, _ := Chain(0, 5)
+	profiler := flow.NewProfiler()
+	blk.AddObserver(profiler)
+	err := blocks.TestUnary(blk, 1, 6, "IN", "OUT", "inc_chain")
+	if err != nil {
+		t.Error(err.Info)
+	}
+
+	nodes := profiler.Nodes()
+	if len(nodes) != 5 {
+		t.Fatal("Profiled ", len(nodes), " nodes.")
+	}
+	for _, np := range nodes {
+		if np.Runs != 1 || np.Compute < 0 || np.Wait < 0 {
+			t.Error("Wrong node profile: ", np)
+		}
+	}
+
+	// Each increment is made ready by the one before
+	path := profiler.CriticalPath()
+	if len(path) != 5 {
+		t.Fatal("Critical path has ", len(path), " steps.")
+	}
+	for i, step := range path {
+		if step.Addr != (flow.Address{"increment", flow.InstanceID(i)}) {
+			t.Error("Wrong step ", i, ": ", step.Addr)
+		}
+	}
+
+	var table bytes.Buffer
+	if err := profiler.WriteTable(&table); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(table.String(), "increment:4") || !strings.Contains(table.String(), "CRITICAL PATH") {
+		t.Error("Wrong table: ", table.String())
+	}
+}
+
+func TestProfileLoop(t *testing.T) {
+	blk, _ := Power(0)
+	profiler := flow.NewProfiler()
+	blk.AddObserver(profiler)
+	err := blocks.TestBinary(blk, 2.0, 3, 8.0, "X", "N", "OUT", "power_loop")
+	if err != nil {
+		t.Error(err.Info)
+	}
+
+	// Iterations are summed into one profile
+	nodes := profiler.Nodes()
+	if len(nodes) != 1 || nodes[0].Runs != 3 {
+		t.Error("Wrong node profiles: ", nodes)
+	}
+	loops := profiler.Loops()
+	if len(loops) != 1 || loops[0].Iterations != 3 || loops[0].Total <= 0 {
+		t.Error("Wrong loop profiles: ", loops)
+	}
+
+	var buf bytes.Buffer
+	if err := profiler.WritePprof(&buf); err != nil {
+		t.Fatal(err)
+	}
+	zr, gz_err := gzip.NewReader(&buf)
+	if gz_err != nil {
+		t.Fatal(gz_err)
+	}
+	data, _ := ioutil.ReadAll(zr)
+	if !bytes.Contains(data, []byte("power_loop:0")) || !bytes.Contains(data, []byte("compute")) {
+		t.Error("Wrong pprof profile.")
+	}
+
+	profiler.Reset()
+	if len(profiler.Nodes()) != 0 || len(profiler.CriticalPath()) != 0 {
+		t.Error("Reset kept measurements.")
+	}
+}
+func TestProfileConcurrent(t *testing.T) {
+	profiler := flow.NewProfiler()
+	addr := flow.Address{"increment", 0}
+	src := flow.ParamAddress{Name: "IN", Addr: flow.Address{"chain", 0}}
+	dst := flow.ParamAddress{Name: "IN", Addr: addr}
+
+	// Two runs of the same address overlap, only the first waited on its input
+	ins1, ins2 := flow.ParamValues{"IN": 1}, flow.ParamValues{"IN": 2}
+	profiler.OnValuePassed(src, dst, 1)
+	time.Sleep(10 * time.Millisecond)
+	profiler.OnNodeStart(addr, ins1)
+	profiler.OnNodeStart(addr, ins2)
+	profiler.OnNodeFinish(addr, ins1, flow.ParamValues{"OUT": 2}, time.Millisecond)
+	profiler.OnNodeFinish(addr, ins2, flow.ParamValues{"OUT": 3}, time.Millisecond)
+
+	nodes := profiler.Nodes()
+	if len(nodes) != 1 || nodes[0].Runs != 2 || nodes[0].Overhead < 10*time.Millisecond {
+		t.Error("Wrong profile: ", nodes)
+	}
+}
+func TestProfileError(t *testing.T) {
+	profiler := flow.NewProfiler()
+	parse_addr, plus_addr := flow.Address{"string_to_int", 0}, flow.Address{"numeric_plus_int", 0}
+
+	// Adds X to the Int parsed from IN
+	build := func() *flow.Graph {
+		ins := flow.ParamTypes{"IN": flow.String, "X": flow.Int}
+		outs := flow.ParamTypes{"OUT": flow.Int}
+		g, _ := flow.NewGraph("parse_plus", ins, outs)
+		parse, _ := blocks.StringtoInt(0)
+		plus, _ := blocks.PlusInt(0)
+		g.AddNode(parse, parse_addr)
+		g.AddNode(plus, plus_addr)
+		g.LinkIn("IN", "IN", parse_addr)
+		g.AddConstant(10, parse_addr, "Base")
+		g.LinkIn("X", "B", plus_addr)
+		g.AddEdge(parse_addr, "OUT", plus_addr, "A")
+		g.LinkOut(plus_addr, "OUT", "OUT")
+		g.AddObserver(profiler)
+		return g
+	}
+
+	// The sum got X, but never starts once parsing fails
+	_, err := blocks.RunBlock(build(), flow.ParamValues{"IN": "x", "X": 4})
+	if err == nil || err.Class != flow.VALUE_ERROR {
+		t.Fatal("Invalid Int parsed.")
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	// The next run does not wait on the input of the failed one
+	err = blocks.TestBinary(build(), "3", 4, 7, "IN", "X", "OUT", "parse_plus")
+	if err != nil {
+		t.Error(err.Info)
+	}
+	for _, np := range profiler.Nodes() {
+		if np.Addr == plus_addr && (np.Runs != 1 || np.Wait >= 50*time.Millisecond) {
+			t.Error("Counted the failed run: ", np)
+		}
+	}
+}
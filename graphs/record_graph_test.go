@@ -0,0 +1,120 @@
+package graphs
+
+import (
+	".."
+	"../blocks"
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// Record and Replay
+
+// Adds a random number to IN.
+func noisy() *flow.Graph {
+	ins := flow.ParamTypes{"IN": flow.Int}
+	outs := flow.ParamTypes{"OUT": flow.Int}
+	graph, _ := flow.NewGraph("noisy", ins, outs)
+	runfunc := func(inputs flow.ParamValues, outputs chan flow.ParamValues, stop chan bool, err chan *flow.Error) {
+		outputs <- flow.ParamValues{"OUT": rand.Int()}
+	}
+	random := flow.Nondeterministic(flow.NewPrimitive("random", runfunc, flow.ParamTypes{}, flow.ParamTypes{"OUT": flow.Int}))
+	random_addr := flow.Address{"random", 0}
+	plus, plus_addr := blocks.PlusInt(0)
+	graph.AddNode(random, random_addr)
+	graph.AddNode(plus, plus_addr)
+	graph.LinkIn("IN", "A", plus_addr)
+	graph.AddEdge(random_addr, "OUT", plus_addr, "B")
+	graph.LinkOut(plus_addr, "OUT", "OUT")
+	return graph
+}
+
+func TestRecordReplay(t *testing.T) {
+	blk := noisy()
+	rec, r_err := flow.Record(blk, flow.ParamValues{"IN": 1}, 0)
+	if r_err != nil {
+		t.Fatal(r_err.Info)
+	}
+	if len(rec.Blocks) != 1 || rec.Outputs == nil {
+		t.Fatal("Wrong recording: ", rec.Blocks, rec.Outputs)
+	}
+
+	// Replay from a file
+	var buf bytes.Buffer
+	if err := rec.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	rec, err := flow.ReadRecording(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, div, f_err := flow.Replay(blk, rec, 0)
+	switch {
+	case div != nil:
+		t.Error(div.Error())
+	case f_err != nil:
+		t.Error(f_err.Info)
+	case out["OUT"] != rec.Outputs["OUT"]:
+		t.Error("Replay returned ", out["OUT"], " instead of ", rec.Outputs["OUT"])
+	}
+}
+func TestReplayRace(t *testing.T) {
+	blk, _ := Race(0)
+	rec, r_err := flow.Record(blk, flow.ParamValues{"IN": 3}, 0)
+	if r_err != nil {
+		t.Fatal(r_err.Info)
+	}
+	out, div, f_err := flow.Replay(blk, rec, 0)
+	if div != nil || f_err != nil || out["PORT"] != rec.Outputs["PORT"] {
+		t.Error("Wrong replay: ", out, div, f_err)
+	}
+}
+func TestReplayDivergence(t *testing.T) {
+	blk, _ := Nand(0)
+	rec, r_err := flow.Record(blk, flow.ParamValues{"A": true, "B": true}, 0)
+	if r_err != nil {
+		t.Fatal(r_err.Info)
+	}
+
+	// The and node now returns false, where the recording has true
+	rec.Inputs["B"] = false
+	_, div, f_err := flow.Replay(blk, rec, 0)
+	switch {
+	case f_err != nil:
+		t.Error(f_err.Info)
+	case div == nil:
+		t.Error("Replay did not diverge.")
+	case div.Got != false || div.Expected != true:
+		t.Error("Wrong divergence: ", div.Error())
+	}
+}
+func TestReplayNested(t *testing.T) {
+	// Both nested graphs hold nodes at the same addresses
+	ins := flow.ParamTypes{"IN": flow.Int}
+	outs := flow.ParamTypes{"OUT": flow.Int}
+	graph, _ := flow.NewGraph("noisy_diff", ins, outs)
+	a_addr, b_addr := flow.Address{"noisy", 0}, flow.Address{"noisy", 1}
+	sub, sub_addr := blocks.SubInt(0)
+	graph.AddNode(noisy(), a_addr)
+	graph.AddNode(noisy(), b_addr)
+	graph.AddNode(sub, sub_addr)
+	graph.LinkIn("IN", "IN", a_addr)
+	graph.LinkIn("IN", "IN", b_addr)
+	graph.AddEdge(a_addr, "OUT", sub_addr, "A")
+	graph.AddEdge(b_addr, "OUT", sub_addr, "B")
+	graph.LinkOut(sub_addr, "OUT", "OUT")
+
+	rec, r_err := flow.Record(graph, flow.ParamValues{"IN": 1}, 0)
+	if r_err != nil {
+		t.Fatal(r_err.Info)
+	}
+	if len(rec.Blocks) != 2 || rec.Blocks[0].Path[0] == rec.Blocks[1].Path[0] {
+		t.Fatal("Wrong recording: ", rec.Blocks)
+	}
+	for i := 0; i < 20; i++ {
+		out, div, f_err := flow.Replay(graph, rec, 0)
+		if div != nil || f_err != nil || out["OUT"] != rec.Outputs["OUT"] {
+			t.Fatal("Wrong replay: ", out, div, f_err)
+		}
+	}
+}
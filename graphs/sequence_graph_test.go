@@ -0,0 +1,72 @@
+package graphs
+
+import (
+	".."
+	"../blocks"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Creates a block which appends its name to order after sleeping for wait.
+func recorder(name string, wait time.Duration, order *[]string, lock *sync.Mutex) (flow.FunctionBlock, flow.Address) {
+	runfunc := func(inputs flow.ParamValues,
+		outputs chan flow.ParamValues,
+		stop chan bool,
+		err chan *flow.Error) {
+		time.Sleep(wait)
+		lock.Lock()
+		*order = append(*order, name)
+		lock.Unlock()
+		outputs <- flow.ParamValues{"OUT": inputs["IN"]}
+	}
+	ins := flow.ParamTypes{"IN": flow.Int}
+	outs := flow.ParamTypes{"OUT": flow.Int}
+	return flow.NewPrimitive(name, runfunc, ins, outs), flow.Address{name, 0}
+}
+
+// Control Edges
+
+func TestControlEdge(t *testing.T) {
+	order, lock := []string{}, &sync.Mutex{}
+	ins := flow.ParamTypes{"IN": flow.Int}
+	outs := flow.ParamTypes{"A": flow.Int, "B": flow.Int}
+	g, _ := flow.NewGraph("ordered", ins, outs)
+	a, a_addr := recorder("write", 5*time.Millisecond, &order, lock)
+	b, b_addr := recorder("read", 0, &order, lock)
+	g.AddNode(a, a_addr)
+	g.AddNode(b, b_addr)
+	g.LinkIn("IN", "IN", a_addr)
+	g.LinkIn("IN", "IN", b_addr)
+	g.LinkOut(a_addr, "OUT", "A")
+	g.LinkOut(b_addr, "OUT", "B")
+	if err := g.AddSequence(a_addr, b_addr); err != nil {
+		t.Fatal(err.Info)
+	}
+
+	_, err := blocks.RunBlock(g, flow.ParamValues{"IN": 1})
+	switch {
+	case err != nil:
+		t.Error(err.Info)
+	case len(order) != 2 || order[0] != "write":
+		t.Error("Nodes ran out of order: ", order)
+	case len(g.GetControlEdges()[a_addr]) != 1:
+		t.Error("Control edge is not visible.")
+	}
+}
+func TestControlCycle(t *testing.T) {
+	ins := flow.ParamTypes{"IN": flow.Int}
+	outs := flow.ParamTypes{"OUT": flow.Int}
+	g, _ := flow.NewGraph("cycle", ins, outs)
+	inc, inc_addr := blocks.Inc(0)
+	dec, dec_addr := blocks.Dec(0)
+	g.AddNode(inc, inc_addr)
+	g.AddNode(dec, dec_addr)
+	g.AddEdge(inc_addr, "OUT", dec_addr, "IN")
+	if err := g.AddControlEdge(dec_addr, inc_addr); err == nil {
+		t.Error("Control edge against a data edge was not rejected.")
+	}
+	if err := g.AddControlEdge(inc_addr, dec_addr); err != nil {
+		t.Error(err.Info)
+	}
+}
@@ -0,0 +1,70 @@
+package graphs
+
+import (
+	".."
+	"../blocks"
+)
+
+// Increments IN if Condition is true and decrements it otherwise,
+// only running the side of the switch which was chosen.
+func Branch(id flow.InstanceID) (*flow.Graph, flow.Address) {
+	// Create Graph
+	ins := flow.ParamTypes{"IN": flow.Int, "Condition": flow.Bool}
+	outs := flow.ParamTypes{"OUT": flow.Int}
+	graph, _ := flow.NewGraph("branch", ins, outs)
+	addr := flow.Address{"branch", id}
+
+	// Create Blocks
+	split, split_addr := blocks.OutputSwitch(0, flow.Int)
+	inc, inc_addr := blocks.Inc(0)
+	dec, dec_addr := blocks.Dec(0)
+	merge, merge_addr := blocks.Merge(0, flow.Int)
+
+	// Add Nodes
+	graph.AddNode(split, split_addr)
+	graph.AddNode(inc, inc_addr)
+	graph.AddNode(dec, dec_addr)
+	graph.AddNode(merge, merge_addr)
+
+	// Add Edges
+	graph.LinkIn("IN", "IN", split_addr)
+	graph.LinkIn("Condition", "Condition", split_addr)
+	graph.AddEdge(split_addr, "A", inc_addr, "IN")
+	graph.AddEdge(split_addr, "B", dec_addr, "IN")
+	graph.AddEdge(inc_addr, "OUT", merge_addr, "A")
+	graph.AddEdge(dec_addr, "OUT", merge_addr, "B")
+	graph.LinkOut(merge_addr, "OUT", "OUT")
+
+	return graph, addr
+}
+
+// Races a slow path against a fast path, forwarding IN from whichever finishes first.
+func Race(id flow.InstanceID) (*flow.Graph, flow.Address) {
+	// Create Graph
+	ins := flow.ParamTypes{"IN": flow.Int}
+	outs := flow.ParamTypes{"OUT": flow.Int, "PORT": flow.String}
+	graph, _ := flow.NewGraph("race", ins, outs)
+	addr := flow.Address{"race", id}
+
+	// Create Blocks
+	slow, slow_addr := blocks.Delay(0, flow.Int)
+	fast, fast_addr := blocks.Delay(1, flow.Int)
+	sel, sel_addr := blocks.Select(0, flow.Int, 2)
+
+	// Add Nodes
+	graph.AddNode(slow, slow_addr)
+	graph.AddNode(fast, fast_addr)
+	graph.AddNode(sel, sel_addr)
+
+	// Add Edges
+	graph.LinkIn("IN", "IN", slow_addr)
+	graph.LinkIn("IN", "IN", fast_addr)
+	graph.AddConstant(1000, slow_addr, "Wait")
+	graph.AddConstant(0, fast_addr, "Wait")
+	graph.AddEdge(slow_addr, "OUT", sel_addr, "IN0")
+	graph.AddEdge(fast_addr, "OUT", sel_addr, "IN1")
+	graph.LinkOut(sel_addr, "OUT", "OUT")
+	graph.LinkOut(sel_addr, "PORT", "PORT")
+
+	return graph, addr
+}
@@ -0,0 +1,59 @@
+package graphs
+
+import (
+	".."
+	"../blocks"
+	"testing"
+	"time"
+)
+
+// Switches
+
+func TestBranch(t *testing.T) {
+	name := "branch"
+	blk, _ := Branch(0)
+	for _, cnd := range []bool{true, false, true} {
+		c := 4
+		if cnd {
+			c = 6
+		}
+		err := blocks.TestBinary(blk, 5, cnd, c, "IN", "Condition", "OUT", name)
+		if err != nil {
+			t.Error(err.Info)
+		}
+	}
+}
+func TestRace(t *testing.T) {
+	blk, _ := Race(0)
+	for i := 0; i < 3; i++ {
+		start := time.Now()
+		out, err := blocks.RunBlock(blk, flow.ParamValues{"IN": i})
+		switch {
+		case err != nil:
+			t.Error(err.Info)
+		case out["OUT"] != i:
+			t.Error("Not the right value: ", out["OUT"])
+		case out["PORT"] != "IN1":
+			t.Error("The slow path won: ", out["PORT"])
+		case time.Since(start) > 500*time.Millisecond:
+			t.Error("Waited for the slow path.")
+		}
+	}
+}
+func TestUnsetOutputs(t *testing.T) {
+	ins := flow.ParamTypes{"IN": flow.Int, "Condition": flow.Bool}
+	outs := flow.ParamTypes{"A": flow.Int, "B": flow.Int}
+	graph, _ := flow.NewGraph("split", ins, outs)
+	split, split_addr := blocks.OutputSwitch(0, flow.Int)
+	graph.AddNode(split, split_addr)
+	graph.LinkIn("IN", "IN", split_addr)
+	graph.LinkIn("Condition", "Condition", split_addr)
+	graph.LinkOut(split_addr, "A", "A")
+	graph.LinkOut(split_addr, "B", "B")
+
+	// Only the side of the switch which was chosen is returned
+	out, err := blocks.RunBlock(graph, flow.ParamValues{"IN": 5, "Condition": false})
+	if _, exists := out["A"]; err != nil || exists || out["B"] != 5 {
+		t.Error("Wrong outputs: ", out, err)
+	}
+}
@@ -0,0 +1,82 @@
+package graphs
+
+import (
+	".."
+	"../blocks"
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// Tracing
+
+func TestTrace(t *testing.T) {
+	name := "power_loop"
+	blk, _ := Power(0)
+	tracer := flow.NewTracer()
+	blk.SetTracer(tracer)
+	err := blocks.TestBinary(blk, 2.0, 3, 8.0, "X", "N", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+
+	// Every iteration runs the multiplication node once
+	iterations, nodes := 0, 0
+	for _, ev := range tracer.Events() {
+		switch ev.Kind {
+		case flow.ITERATION_EVENT:
+			if ev.Addr.Name != name || ev.Iteration != iterations {
+				t.Error("Wrong iteration event: ", ev.Addr, ev.Iteration)
+			}
+			iterations += 1
+		case flow.NODE_EVENT:
+			if ev.Inputs["A"] != 2.0 || ev.Outputs["OUT"] == nil {
+				t.Error("Wrong node values: ", ev.Inputs, ev.Outputs)
+			}
+			nodes += 1
+		}
+		if ev.End.Before(ev.Start) {
+			t.Error("Event ends before it starts.")
+		}
+	}
+	if iterations != 3 || nodes != 3 {
+		t.Error("Traced ", iterations, " iterations and ", nodes, " nodes.")
+	}
+
+	// Export
+	var buf bytes.Buffer
+	if err := tracer.WriteChrome(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var trace struct {
+		TraceEvents []map[string]interface{} `json:"traceEvents"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &trace); err != nil {
+		t.Fatal(err)
+	}
+	if len(trace.TraceEvents) != 6 || trace.TraceEvents[0]["ph"] != "X" {
+		t.Error("Wrong chrome trace: ", buf.String())
+	}
+}
+func TestTraceRedact(t *testing.T) {
+	name := "logical_nand"
+	blk, _ := Nand(0)
+	tracer := flow.NewTracer()
+	tracer.SetRedact(flow.RedactAll)
+	blk.SetTracer(tracer)
+	err := blocks.TestBinary(blk, true, true, false, "A", "B", "OUT", name)
+	if err != nil {
+		t.Error(err.Info)
+	}
+	events := tracer.Events()
+	if len(events) != 2 {
+		t.Error("Traced ", len(events), " nodes.")
+	}
+	for _, ev := range events {
+		for _, val := range ev.Inputs {
+			if val != "<redacted>" {
+				t.Error("Value not redacted: ", val)
+			}
+		}
+	}
+}
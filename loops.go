@@ -2,28 +2,56 @@ package flow
 
 import (
 	"fmt"
+	"log/slog"
+	"time"
 )
 
 const (
 	INDEX_NAME = "I"
 	DONE_NAME  = "DONE"
+	N_NAME     = "N"
+	COUNT_NAME = "COUNT"
+)
+
+// Decides how a loop output is built from the values of each iteration
+type TunnelMode int
+
+// Tunnel modes:
+const (
+	LAST_VALUE    TunnelMode = iota // Output the value of the last iteration
+	INDEXING      TunnelMode = iota // Collect the value of every iteration into an array
+	CONDITIONAL   TunnelMode = iota // Collect the value of iterations where a boolean output is true
+	CONCATENATING TunnelMode = iota // Append the arrays of every iteration into one array
 )
 
 type Loop struct {
 	name string
-	g    *Graph
+	blk  FunctionBlock // Run once every iteration
+	cnd  FunctionBlock // Evaluated before each iteration of a while loop, nil otherwise
+
+	count    bool    // If true the loop runs the number of times given by the N input
+	cnd_out  string  // The boolean output of cnd which keeps the loop running
+	cnd_feed NameMap // Connects name of a param in cnd to the name of an input of blk
 
-	infeed  ParamLstMap // Connects name of a param in inputs to a ParamAddress of some parameter in some node
-	outfeed ParamMap    // Connects name of a param in outputs to a ParamAddress of some parameter in some node
-	inputs  ParamTypes
-	outputs ParamTypes
+	infeed     ParamLstMap           // Connects name of a param in inputs to a ParamAddress of some parameter in some node
+	outfeed    ParamMap              // Connects name of a param in outputs to a ParamAddress of some parameter in some node
+	modes      map[string]TunnelMode // Connects name of a param in outputs to the way it collects values
+	conditions NameMap               // Connects name of a conditional output to the boolean output of blk which enables it
+	inputs     ParamTypes
+	outputs    ParamTypes
 
 	sources   map[ParamAddress]ParamAddress
 	registers NameMap
 	initial   ParamValues
+	stacks    map[string][]string // Connects the newest input of a stacked register to the inputs holding older values
+
+	env *env // Settings shared with every block nested inside the loop
+
+	checkpoint string // File the state of a run is saved to, none if empty
+	every      int    // Number of iterations between two checkpoints
 }
 
-func NewLoop(name string, inputs, outputs ParamTypes, blk *Graph) (*Loop, *Error) {
+func NewLoop(name string, inputs, outputs ParamTypes, blk FunctionBlock) (*Loop, *Error) {
 
 	// Check that stop_condition has one bool output.
 	_, cnd_out := blk.GetParams()
@@ -38,16 +66,78 @@ func NewLoop(name string, inputs, outputs ParamTypes, blk *Graph) (*Loop, *Error
 		return nil, &Error{TYPE_ERROR, "Block has no boolean output."}
 	}
 
-	// Initialize variables
+	// Build Loop
+	return newLoop(name, inputs, outputs, blk)
+
+}
+
+// Initializes the parameter maps shared by every kind of loop.
+// Adds the COUNT output, which holds the number of iterations run.
+func newLoop(name string, inputs, outputs ParamTypes, blk FunctionBlock) (*Loop, *Error) {
+	t, exists := outputs[COUNT_NAME]
+	if exists && t != Int {
+		return nil, &Error{TYPE_ERROR, "COUNT output must be of type Int."}
+	}
+	outputs = outputs.Copy()
+	outputs[COUNT_NAME] = Int
+
 	regs, inits := make(NameMap), make(ParamValues)
 	infeed, outfeed := make(ParamLstMap), make(ParamMap)
 	sources := make(map[ParamAddress]ParamAddress)
+	e := newEnv()
+	return &Loop{name: name, blk: adopt(blk, e, Address{blk.GetName(), 0}), infeed: infeed, outfeed: outfeed,
+		inputs: inputs, outputs: outputs, sources: sources, registers: regs, initial: inits,
+		stacks: make(map[string][]string), modes: make(map[string]TunnelMode), conditions: make(NameMap), env: e}, nil
+}
+
+// Creates a loop which runs blk the number of times given by its N input.
+// The N input is added to inputs and does not need to be linked to blk.
+// DONE may still be linked to end the loop early.
+func NewForLoop(name string, inputs, outputs ParamTypes, blk FunctionBlock) (*Loop, *Error) {
+	t, exists := inputs[N_NAME]
+	if exists && t != Int {
+		return nil, &Error{TYPE_ERROR, "N input must be of type Int."}
+	}
+	inputs = inputs.Copy()
+	inputs[N_NAME] = Int
 
 	// Build Loop
-	outLoop := Loop{name, blk, infeed, outfeed, inputs, outputs, sources, regs, inits}
+	outLoop, err := newLoop(name, inputs, outputs, blk)
+	if err != nil {
+		return nil, err
+	}
+	outLoop.count = true
 
-	return &outLoop, nil
+	return outLoop, nil
+}
 
+// Creates a loop which evaluates cnd before each iteration and runs blk while it returns true.
+// cnd must have exactly one boolean output, its inputs are linked with LinkCondition.
+func NewWhileLoop(name string, inputs, outputs ParamTypes, blk FunctionBlock, cnd FunctionBlock) (*Loop, *Error) {
+
+	// Check that cnd has one bool output.
+	_, cnd_outs := cnd.GetParams()
+	cnd_out := ""
+	for out_name, t := range cnd_outs {
+		if t == Bool {
+			if cnd_out != "" {
+				return nil, &Error{TYPE_ERROR, "Condition has more than one boolean output."}
+			}
+			cnd_out = out_name
+		}
+	}
+	if cnd_out == "" {
+		return nil, &Error{TYPE_ERROR, "Condition has no boolean output."}
+	}
+
+	// Build Loop
+	outLoop, err := newLoop(name, inputs, outputs, blk)
+	if err != nil {
+		return nil, err
+	}
+	outLoop.cnd, outLoop.cnd_out, outLoop.cnd_feed = adopt(cnd, outLoop.env, Address{cnd.GetName(), 1}), cnd_out, make(NameMap)
+
+	return outLoop, nil
 }
 
 // FunctionBlock Fields
@@ -58,10 +148,10 @@ func (l Loop) GetParams() (inputs ParamTypes, outputs ParamTypes) {
 
 // Loop Fields
 func (l Loop) LinkIn(self_param_name string, in_param_name string) *Error {
-	g_ins, _ := l.g.GetParams()
+	g_ins, _ := l.blk.GetParams()
 	g_type, g_exists := g_ins[in_param_name]
 	self_type, self_exists := l.inputs[self_param_name]
-	in_param := ParamAddress{in_param_name, Address{l.g.GetName(), 0}, g_type, true}
+	in_param := ParamAddress{in_param_name, Address{l.blk.GetName(), 0}, g_type, true}
 	_, link_exists := l.sources[in_param]
 	_, other_links := l.infeed[self_param_name]
 	if self_param_name == INDEX_NAME {
@@ -70,7 +160,7 @@ func (l Loop) LinkIn(self_param_name string, in_param_name string) *Error {
 	}
 	switch {
 	case !g_exists:
-		return &Error{DNE_ERROR, "out_param_name of inner graph does not exist."}
+		return &Error{DNE_ERROR, "out_param_name of inner block does not exist."}
 	case !self_exists:
 		return &Error{DNE_ERROR, "self_param_name does not exist."}
 	case link_exists:
@@ -86,54 +176,177 @@ func (l Loop) LinkIn(self_param_name string, in_param_name string) *Error {
 	return nil
 }
 func (l Loop) LinkOut(out_param_name string, self_param_name string) *Error {
-	_, g_outs := l.g.GetParams()
+	return l.linkOut(out_param_name, self_param_name, LAST_VALUE, "")
+}
+
+// Connects out_param_name of the inner block to self_param_name with the given tunnel mode.
+func (l Loop) linkOut(out_param_name, self_param_name string, mode TunnelMode, cnd_name string) *Error {
+	_, g_outs := l.blk.GetParams()
 	g_type, g_exists := g_outs[out_param_name]
 	self_type, self_exists := l.outputs[self_param_name]
 	_, link_exists := l.outfeed[self_param_name]
-	out_param := ParamAddress{out_param_name, Address{l.g.GetName(), 0}, g_type, true}
+	out_param := ParamAddress{out_param_name, Address{l.blk.GetName(), 0}, g_type, true}
 	if self_param_name == DONE_NAME {
 		self_type = Bool
 		self_exists = true
 	}
+
+	// Array modes build a NumArray from the values of each iteration
+	var type_ok bool
+	switch mode {
+	case INDEXING, CONDITIONAL:
+		type_ok = self_type == NumArray && CheckSame(g_type, Num)
+	case CONCATENATING:
+		type_ok = self_type == NumArray && g_type == NumArray
+	default:
+		type_ok = CheckSame(g_type, self_type)
+	}
+
 	switch {
 	case !g_exists:
-		return &Error{DNE_ERROR, "out_param_name of inner graph does not exist."}
+		return &Error{DNE_ERROR, "out_param_name of inner block does not exist."}
 	case !self_exists:
 		return &Error{DNE_ERROR, "self_param_name does not exist."}
 	case link_exists:
 		return &Error{ALREADY_EXISTS_ERROR, "self_param_name is already connected to a parameter."}
-	case !CheckSame(g_type, self_type):
+	case self_param_name == COUNT_NAME:
+		return &Error{ALREADY_EXISTS_ERROR, "COUNT is set by the loop itself."}
+	case self_param_name == DONE_NAME && mode != LAST_VALUE:
+		return &Error{VALUE_ERROR, "DONE can only be linked as a last value."}
+	case !type_ok:
 		return &Error{TYPE_ERROR, "Types are not compatible."}
 	default:
 		l.outfeed[self_param_name] = out_param
+		l.modes[self_param_name] = mode
+		if mode == CONDITIONAL {
+			l.conditions[self_param_name] = cnd_name
+		}
 	}
 	return nil
 }
 
 // --------------- Novel Methods --------------
 
-// Connects out_name parameter of the inner graph to in_name parameter of the inner graph.
+// Sets the scheduler which starts the nodes of every graph nested inside the loop.
+func (l *Loop) SetScheduler(sched Scheduler) {
+	l.env.sched = sched
+}
+
+// Runs the nodes of every graph nested inside the loop one at a time in a stable order,
+// and seeds every random primitive inside it from seed.
+func (l *Loop) SetDeterministic(seed int64) {
+	l.env.sched = NewSerialScheduler()
+	l.env.rng = newRand(seed)
+}
+
+// Caches the outputs of every pure node nested inside the loop in cache. A nil cache turns caching off.
+// Nested blocks which set their own cache use it instead.
+func (l *Loop) SetCache(cache *Cache) {
+	l.env.setCache(cache)
+}
+
+// Sets the cluster running the nodes placed on workers in every graph nested inside the loop.
+func (l *Loop) SetCluster(c *Cluster) {
+	l.env.cluster = c
+}
+
+// Saves the state of every run to path after each every iterations, see Resume.
+// An empty path turns checkpoints off.
+func (l *Loop) SetCheckpoint(path string, every int) {
+	if every < 1 {
+		every = 1
+	}
+	l.checkpoint, l.every = path, every
+}
+
+// Logs every run of the loop, and of every block nested inside it, to logger. A nil logger logs nothing.
+func (l *Loop) SetLogger(logger *slog.Logger) {
+	l.env.setLogger(logger)
+}
+
+// Records every iteration of the loop, and every node run nested inside it, in tracer.
+// Same as AddObserver, except that it replaces the tracer set before. A nil tracer turns tracing off.
+func (l *Loop) SetTracer(tracer *Tracer) {
+	l.env.setTracer(tracer)
+}
+
+// Sends the events of every run of the loop, and of every block nested inside it, to obs.
+func (l *Loop) AddObserver(obs Observer) {
+	l.env.observers = append(l.env.observers, obs)
+}
+
+// Connects out_param_name of the inner block to self_param_name using an output tunnel mode.
+// INDEXING and CONCATENATING outputs must be of type NumArray.
+// CONDITIONAL outputs need a condition and are linked with LinkOutConditional instead.
+func (l Loop) LinkOutMode(out_param_name string, self_param_name string, mode TunnelMode) *Error {
+	if mode == CONDITIONAL {
+		return &Error{NOT_READY_ERROR, "Conditional outputs need a condition, use LinkOutConditional."}
+	}
+	return l.linkOut(out_param_name, self_param_name, mode, "")
+}
+
+// Connects out_param_name of the inner block to the NumArray self_param_name,
+// collecting its value only on iterations where the boolean output cnd_name of the inner block is true.
+func (l Loop) LinkOutConditional(out_param_name, cnd_name, self_param_name string) *Error {
+	_, g_outs := l.blk.GetParams()
+	t, exists := g_outs[cnd_name]
+	switch {
+	case !exists:
+		return &Error{DNE_ERROR, "cnd_name of inner block does not exist."}
+	case t != Bool:
+		return &Error{TYPE_ERROR, "cnd_name must be of type Bool."}
+	}
+	return l.linkOut(out_param_name, self_param_name, CONDITIONAL, cnd_name)
+}
+
+// Connects in_name input of the inner block to cnd_param_name input of the condition of a while loop.
+// Before each iteration the condition receives the value in_name is about to receive,
+// which includes the index, loop inputs and register values.
+func (l Loop) LinkCondition(in_name, cnd_param_name string) *Error {
+	if l.cnd == nil {
+		return &Error{NOT_READY_ERROR, "Loop has no condition."}
+	}
+	g_ins, _ := l.blk.GetParams()
+	cnd_ins, _ := l.cnd.GetParams()
+	g_type, g_exists := g_ins[in_name]
+	cnd_type, cnd_exists := cnd_ins[cnd_param_name]
+	_, link_exists := l.cnd_feed[cnd_param_name]
+	switch {
+	case !g_exists:
+		return &Error{DNE_ERROR, "in_name is not a parameter of inner block."}
+	case !cnd_exists:
+		return &Error{DNE_ERROR, "cnd_param_name is not a parameter of condition."}
+	case link_exists:
+		return &Error{ALREADY_EXISTS_ERROR, "cnd_param_name is already connected to a parameter."}
+	case !CheckSame(g_type, cnd_type):
+		return &Error{TYPE_ERROR, "Types are not compatible."}
+	default:
+		l.cnd_feed[cnd_param_name] = in_name
+	}
+	return nil
+}
+
+// Connects out_name parameter of the inner block to in_name parameter of the inner block.
 // Creates a default parameter value for the input
 // Assumes a feed_input does not exist for the loop and instead uses a default value
 // Can create an out_feed, will return an error if out_feed name is taken but type is different
 // Will return an error if the in_name parameter is already connected to a register
 func (l Loop) AddDefaultRegister(out_name, in_name string, init interface{}) *Error {
-	ins, outs := l.g.GetParams()
+	ins, outs := l.blk.GetParams()
 	t1, exists1 := ins[in_name]
 	t2, exists2 := outs[out_name]
 	switch {
 	case !exists1:
-		return &Error{DNE_ERROR, "in_name is not a parameter of graph."}
+		return &Error{DNE_ERROR, "in_name is not a parameter of inner block."}
 	case !exists2:
-		return &Error{DNE_ERROR, "out_name is not a parameter of graph."}
+		return &Error{DNE_ERROR, "out_name is not a parameter of inner block."}
 	case !CheckSame(t1, t2):
 		return &Error{TYPE_ERROR, fmt.Sprintf("in_name and out_name are incompatible types: %v, %v", t1, t2)}
 	default:
-		_, connected := l.registers[in_name]
-		if !connected {
+		if !l.registered(in_name) {
 			l.registers[in_name] = out_name
 			l.initial[in_name] = init
-			in_param := ParamAddress{in_name, Address{l.g.GetName(), 0}, t1, true}
+			in_param := ParamAddress{in_name, Address{l.blk.GetName(), 0}, t1, true}
 			l.sources[in_param] = ParamAddress{in_name, Address{l.name, 0}, t1, false}
 		} else {
 			return &Error{ALREADY_EXISTS_ERROR, "Connection to input already exists."}
@@ -147,7 +360,7 @@ func (l Loop) AddDefaultRegister(out_name, in_name string, init interface{}) *Er
 // Will return an error if the in_name parameter is already connected to a register
 func (l Loop) AddRegister(out_name, in_name string, t Type) *Error {
 	// Check input feed
-	ins, outs := l.g.GetParams()
+	ins, outs := l.blk.GetParams()
 	t1, exists1 := ins[in_name]
 	t2, exists2 := outs[out_name]
 	_, feed_exists := l.infeed[in_name]
@@ -155,16 +368,15 @@ func (l Loop) AddRegister(out_name, in_name string, t Type) *Error {
 	// Handle Errors
 	switch {
 	case !exists1:
-		return &Error{DNE_ERROR, "in_name is not a parameter of graph."}
+		return &Error{DNE_ERROR, "in_name is not a parameter of inner block."}
 	case !exists2:
-		return &Error{DNE_ERROR, "out_name is not a parameter of graph."}
+		return &Error{DNE_ERROR, "out_name is not a parameter of inner block."}
 	case !feed_exists:
 		return &Error{DNE_ERROR, "in_name must have a feed connected prior to creating a register"}
-	case !CheckType(t1, t2):
+	case !CheckSame(t1, t2):
 		return &Error{TYPE_ERROR, "in_name and out_name are incompatible types."}
 	default:
-		_, connected := l.registers[in_name]
-		if !connected {
+		if !l.registered(in_name) {
 			l.registers[in_name] = out_name
 		} else {
 			return &Error{ALREADY_EXISTS_ERROR, "Connection to input already exists."}
@@ -173,10 +385,89 @@ func (l Loop) AddRegister(out_name, in_name string, t Type) *Error {
 	return nil
 }
 
+// Connects out_name parameter of the inner block to a stack of inputs of the inner block.
+// in_names[0] receives the value of out_name from the previous iteration, in_names[1] the value
+// from two iterations ago, and so on. inits holds the initial value of each input in in_names.
+// Will return an error if any input is already connected to a register or if any type differs
+func (l Loop) AddStackedRegister(out_name string, in_names []string, inits []interface{}) *Error {
+	ins, outs := l.blk.GetParams()
+	t_out, out_exists := outs[out_name]
+	switch {
+	case len(in_names) == 0:
+		return &Error{DNE_ERROR, "in_names has a length of Zero."}
+	case len(in_names) != len(inits):
+		return &Error{VALUE_ERROR, "in_names and inits have different lengths."}
+	case !out_exists:
+		return &Error{DNE_ERROR, "out_name is not a parameter of inner block."}
+	}
+
+	// Check every input of the stack
+	seen := make(map[string]bool)
+	for i, in_name := range in_names {
+		t_in, in_exists := ins[in_name]
+		switch {
+		case !in_exists:
+			return &Error{DNE_ERROR, "in_name is not a parameter of inner block: " + in_name}
+		case seen[in_name] || l.registered(in_name):
+			return &Error{ALREADY_EXISTS_ERROR, "Connection to input already exists: " + in_name}
+		case !CheckSame(t_in, t_out):
+			return &Error{TYPE_ERROR, fmt.Sprintf("in_name and out_name are incompatible types: %v, %v", t_in, t_out)}
+		case !CheckType(t_in, inits[i]):
+			return &Error{TYPE_ERROR, "Initial value is not the same type as in_name: " + in_name}
+		}
+		seen[in_name] = true
+	}
+
+	// Connect the newest input like any other register, and keep the rest in order
+	l.registers[in_names[0]] = out_name
+	l.stacks[in_names[0]] = append([]string{}, in_names[1:]...)
+	for i, in_name := range in_names {
+		l.initial[in_name] = inits[i]
+		in_param := ParamAddress{in_name, Address{l.blk.GetName(), 0}, ins[in_name], true}
+		l.sources[in_param] = ParamAddress{in_name, Address{l.name, 0}, ins[in_name], false}
+	}
+	return nil
+}
+
+// Returns true if in_name is connected to a register or any level of a stacked register.
+func (l Loop) registered(in_name string) bool {
+	if _, connected := l.registers[in_name]; connected {
+		return true
+	}
+	for _, older := range l.stacks {
+		for _, name := range older {
+			if name == in_name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (l Loop) Run(inputs ParamValues, outputs chan ParamValues, stop chan bool, err chan *FlowError, id InstanceID) {
+	l.run(nil, nil, inputs, outputs, stop, err, id)
+}
+
+func (l Loop) runWatched(w Observer, inputs ParamValues, outputs chan ParamValues, stop chan bool, err chan *FlowError, id InstanceID) {
+	l.run(nil, w, inputs, outputs, stop, err, id)
+}
+
+// Runs the loop like Run, starting from the iteration, register values and outputs saved in cp.
+// inputs must hold the same loop inputs as the run which saved it.
+func (l Loop) Resume(cp *Checkpoint, inputs ParamValues, outputs chan ParamValues, stop chan bool, err chan *FlowError, id InstanceID) {
+	if cp.Loop != l.name {
+		l.env.raise(err, NewFlowError(VALUE_ERROR, "Checkpoint was saved by another loop: "+cp.Loop, Address{l.name, id}))
+		return
+	}
+	l.run(cp, nil, inputs, outputs, stop, err, id)
+}
+
+// Runs the loop, from cp if it is not nil, letting w observe this run only.
+func (l Loop) run(cp *Checkpoint, w Observer, inputs ParamValues, outputs chan ParamValues, stop chan bool, err chan *FlowError, id InstanceID) {
 	// Declare variables
 	ADDR := Address{l.GetName(), id}
-	logger := CreateLogger("none", "[INFO]")
+	log := logWith(l.env.log(), "loop", ADDR)
+	obs := l.env.observer(w)
 	data_out := make(ParamValues)
 	all_done := false
 	loop_i := 0
@@ -187,17 +478,46 @@ func (l Loop) Run(inputs ParamValues, outputs chan ParamValues, stop chan bool,
 
 	// Copy output values to data_out and i_inputs
 	handleOutput := func(out_vals ParamValues) {
+		// Outputs which were not set are treated as missing
+		for name, val := range out_vals {
+			if val == NoValue {
+				delete(out_vals, name)
+			}
+		}
+
 		// Copy output values to data_out
 		for self_name, param := range l.outfeed {
 			val, exists := out_vals[param.Name]
-			if self_name == DONE_NAME && exists {
+			switch {
+			case !exists:
+			case self_name == DONE_NAME:
 				all_done = val.(bool)
-			} else if exists {
+			case l.modes[self_name] == INDEXING:
+				data_out[self_name] = append(data_out[self_name].([]float64), ToNum(val))
+			case l.modes[self_name] == CONDITIONAL:
+				if keep, _ := out_vals[l.conditions[self_name]].(bool); keep {
+					data_out[self_name] = append(data_out[self_name].([]float64), ToNum(val))
+				}
+			case l.modes[self_name] == CONCATENATING:
+				data_out[self_name] = append(data_out[self_name].([]float64), val.([]float64)...)
+			default:
 				data_out[self_name] = val
 			}
 		}
-		logger.Println("Out data: ", data_out)
-		logger.Println("Done: ", all_done)
+		log.Debug("Collected outputs", "iteration", loop_i, "outputs", data_out, "done", all_done)
+
+		// Shift older values down each stacked register
+		for in_name, older := range l.stacks {
+			if _, exists := out_vals[l.registers[in_name]]; !exists {
+				continue
+			}
+			for i := len(older) - 1; i > 0; i-- {
+				i_inputs[older[i]] = i_inputs[older[i-1]]
+			}
+			if len(older) > 0 {
+				i_inputs[older[0]] = i_inputs[in_name]
+			}
+		}
 
 		// Copy output values to i_inputs
 		for in_name, out_name := range l.registers {
@@ -209,7 +529,7 @@ func (l Loop) Run(inputs ParamValues, outputs chan ParamValues, stop chan bool,
 	}
 
 	updateIndex := func(val int) {
-		logger.Println("Iteration ", val)
+		log.Debug("Starting iteration", "iteration", val)
 		param_lst := l.infeed[INDEX_NAME]
 		for _, param := range param_lst {
 			i_inputs[param.Name] = val
@@ -225,7 +545,7 @@ func (l Loop) Run(inputs ParamValues, outputs chan ParamValues, stop chan bool,
 				i_inputs[param.Name] = val
 			}
 		case !exists:
-			err <- NewFlowError(DNE_ERROR, "Not all inputs satisfied: "+name, ADDR)
+			l.env.raise(err, NewFlowError(DNE_ERROR, "Not all inputs satisfied: "+name, ADDR))
 			return
 		}
 	}
@@ -235,25 +555,129 @@ func (l Loop) Run(inputs ParamValues, outputs chan ParamValues, stop chan bool,
 		i_inputs[name] = val
 	}
 
+	// Array outputs start empty
+	for self_name, mode := range l.modes {
+		if mode != LAST_VALUE {
+			data_out[self_name] = []float64{}
+		}
+	}
+
+	// Continue from the checkpoint
+	if cp != nil {
+		log.Debug("Resuming", "iteration", cp.Iteration)
+		for name, val := range cp.Registers {
+			i_inputs[name] = val
+		}
+		for name, val := range cp.Outputs {
+			data_out[name] = val
+		}
+		loop_i, all_done = cp.Iteration, cp.Done
+	}
+
+	// Saves the state between two iterations
+	save := func() bool {
+		regs := make(ParamValues)
+		for name := range l.registers {
+			regs[name] = i_inputs[name]
+		}
+		for name := range l.initial {
+			regs[name] = i_inputs[name]
+		}
+		cp := &Checkpoint{l.name, loop_i, regs, data_out.Copy(), all_done}
+		if save_err := cp.write(l.checkpoint); save_err != nil {
+			l.env.raise(err, NewFlowError(IO_ERROR, "Could not write checkpoint: "+save_err.Error(), ADDR))
+			return false
+		}
+		log.Debug("Saved checkpoint", "iteration", loop_i, "path", l.checkpoint)
+		return true
+	}
+
+	// Read the iteration count of a for loop
+	n := 0
+	if l.count {
+		val, exists := inputs[N_NAME]
+		n_val, ok := val.(int)
+		switch {
+		case !exists:
+			l.env.raise(err, NewFlowError(DNE_ERROR, "Not all inputs satisfied: "+N_NAME, ADDR))
+			return
+		case !ok:
+			l.env.raise(err, NewFlowError(TYPE_ERROR, "N input must be of type Int.", ADDR))
+			return
+		}
+		n = n_val
+	}
+
+	// Runs the condition of a while loop on the current inner inputs
+	runCondition := func() (cont bool, ok bool) {
+		cnd_ins, _ := l.cnd.GetParams()
+		c_inputs := make(ParamValues)
+		for cnd_name := range cnd_ins {
+			in_name, exists := l.cnd_feed[cnd_name]
+			if !exists {
+				l.env.raise(err, NewFlowError(DNE_ERROR, "Not all condition inputs linked: "+cnd_name, ADDR))
+				return false, false
+			}
+			c_inputs[cnd_name] = i_inputs[in_name]
+		}
+		c_out, c_stop, c_err := blockRun(l.cnd, w, c_inputs, 0)
+		select {
+		case vals := <-c_out:
+			cont, _ = vals[l.cnd_out].(bool)
+			return cont, true
+		case <-stop:
+			c_stop <- true
+			return false, false
+		case temp_err := <-c_err:
+			err <- temp_err
+			return false, false
+		}
+	}
+
 	// Run main loop until done is set
 	for !all_done {
+		// For loops end after N iterations
+		if l.count && loop_i >= n {
+			break
+		}
 		updateIndex(loop_i) // Update index input
-		logger.Println(i_inputs)
-		logger.Println(l.g.GetParams())
-		go l.g.Run(i_inputs, i_out, i_stop, i_err, 0) // Run once
+
+		// While loops end when the condition is false
+		if l.cnd != nil {
+			cont, ok := runCondition()
+			if !ok {
+				return
+			} else if !cont {
+				break
+			}
+		}
+		log.Debug("Running block", "iteration", loop_i, "inputs", i_inputs)
+		start := time.Now()
+		go runWatched(l.blk, w, i_inputs, i_out, i_stop, i_err, 0) // Run once
 		select {
 		case data_out := <-i_out: // Listen for data
+			if obs != nil {
+				obs.OnLoopIteration(ADDR, loop_i, i_inputs.Copy(), data_out, time.Since(start))
+			}
 			handleOutput(data_out)
 		case <-stop: // Listen for external stop command
 			i_stop <- true
 			return
 		case temp_err := <-i_err: // Listen for internal error
+			if f, ok := obs.(FailureObserver); ok {
+				f.OnLoopIterationFail(ADDR, loop_i, i_inputs.Copy(), temp_err, time.Since(start))
+			}
 			err <- temp_err
 			i_stop <- true
 			return
 		}
 		loop_i += 1 // Iterate index value
+		if l.checkpoint != "" && loop_i%l.every == 0 && !save() {
+			return
+		}
 	}
+	data_out[COUNT_NAME] = loop_i
+	log.Debug("Finished", "iterations", loop_i)
 	outputs <- data_out
 }
 
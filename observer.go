@@ -0,0 +1,80 @@
+package flow
+
+import (
+	"time"
+)
+
+// Receives events while graphs, loops and blocks run.
+// Methods may be called from many goroutines at once and should return quickly.
+// Maps passed to them are only valid during the call and must not be modified.
+// OnNodeStart and OnNodeFinish receive the same ins map for the same run.
+type Observer interface {
+	OnNodeStart(addr Address, ins ParamValues)                                   // A node starts its block
+	OnNodeFinish(addr Address, ins, outs ParamValues, d time.Duration)           // A node's block returned outputs
+	OnValuePassed(src, dst ParamAddress, val interface{})                        // A value is passed along an edge
+	OnLoopIteration(addr Address, i int, ins, outs ParamValues, d time.Duration) // A loop completed iteration i
+	OnError(err *FlowError)                                                      // A block raised an error
+}
+
+// Observers which also implement FailureObserver hear of node runs and loop iterations
+// which ended in an error instead of returning outputs.
+type FailureObserver interface {
+	OnNodeFail(addr Address, ins ParamValues, err *FlowError, d time.Duration)
+	OnLoopIterationFail(addr Address, i int, ins ParamValues, err *FlowError, d time.Duration)
+}
+
+// Ignores every event. Embed it to implement only some methods of Observer.
+type BaseObserver struct{}
+
+func (o BaseObserver) OnNodeStart(addr Address, ins ParamValues) {}
+
+func (o BaseObserver) OnNodeFinish(addr Address, ins, outs ParamValues, d time.Duration) {}
+
+func (o BaseObserver) OnValuePassed(src, dst ParamAddress, val interface{}) {}
+
+func (o BaseObserver) OnLoopIteration(addr Address, i int, ins, outs ParamValues, d time.Duration) {}
+
+func (o BaseObserver) OnError(err *FlowError) {}
+
+// Passes every event to several observers in order.
+type observers []Observer
+
+func (os observers) OnNodeStart(addr Address, ins ParamValues) {
+	for _, o := range os {
+		o.OnNodeStart(addr, ins)
+	}
+}
+func (os observers) OnNodeFinish(addr Address, ins, outs ParamValues, d time.Duration) {
+	for _, o := range os {
+		o.OnNodeFinish(addr, ins, outs, d)
+	}
+}
+func (os observers) OnValuePassed(src, dst ParamAddress, val interface{}) {
+	for _, o := range os {
+		o.OnValuePassed(src, dst, val)
+	}
+}
+func (os observers) OnLoopIteration(addr Address, i int, ins, outs ParamValues, d time.Duration) {
+	for _, o := range os {
+		o.OnLoopIteration(addr, i, ins, outs, d)
+	}
+}
+func (os observers) OnError(err *FlowError) {
+	for _, o := range os {
+		o.OnError(err)
+	}
+}
+func (os observers) OnNodeFail(addr Address, ins ParamValues, err *FlowError, d time.Duration) {
+	for _, o := range os {
+		if f, ok := o.(FailureObserver); ok {
+			f.OnNodeFail(addr, ins, err, d)
+		}
+	}
+}
+func (os observers) OnLoopIterationFail(addr Address, i int, ins ParamValues, err *FlowError, d time.Duration) {
+	for _, o := range os {
+		if f, ok := o.(FailureObserver); ok {
+			f.OnLoopIterationFail(addr, i, ins, err, d)
+		}
+	}
+}
@@ -18,10 +18,15 @@ type FunctionBlock interface {
 // A primitive function block that only
 // contains a DataStream Function to run
 type PrimitiveBlock struct {
-	name    string
-	fn      DataStream
-	inputs  ParamTypes
-	outputs ParamTypes
+	name      string
+	fn        DataStream
+	random_fn RandomStream // Run instead of fn if set
+	inputs    ParamTypes
+	outputs   ParamTypes
+	rule      FiringRule
+	random    bool // True if the block may return different outputs for the same inputs
+	pure      bool // True if the block always returns the same outputs for the same inputs
+	env       *env // Settings of the graph or loop running the block, nil until it is added to one
 }
 
 // Initializes a FunctionBlock object with given attributes, and an empty parameter list.
@@ -29,16 +34,52 @@ type PrimitiveBlock struct {
 var nblocks map[string]InstanceID = make(map[string]InstanceID)
 
 func NewPrimitive(name string, function DataStream, inputs ParamTypes, outputs ParamTypes) FunctionBlock {
+	return NewPrimitiveRule(name, function, inputs, outputs, ALL_OF)
+}
+
+// Initializes a FunctionBlock which runs in a graph following the given firing rule.
+// Blocks using MERGE receive NoValue for inputs which were not set,
+// blocks using ANY_OF receive only the first input which was set.
+func NewPrimitiveRule(name string, function DataStream, inputs ParamTypes, outputs ParamTypes, rule FiringRule) FunctionBlock {
 	nblocks[name] += 1
 	return PrimitiveBlock{name: name,
 		fn:      function,
 		inputs:  inputs,
-		outputs: outputs}
+		outputs: outputs,
+		rule:    rule}
 }
 
 // Returns a copy of FunctionBlock's InstanceId
 func (m PrimitiveBlock) GetName() string { return m.name }
 
+// Returns the rule by which this block runs in a graph
+func (m PrimitiveBlock) GetFiringRule() FiringRule { return m.rule }
+
+// Returns true if the block may return different outputs for the same inputs
+func (m PrimitiveBlock) IsNondeterministic() bool { return m.random }
+
+// Returns true if the block always returns the same outputs for the same inputs
+func (m PrimitiveBlock) IsPure() bool { return m.pure }
+
+// Marks a primitive block as pure, so its outputs can be cached. Other blocks are returned unchanged.
+func Pure(blk FunctionBlock) FunctionBlock {
+	if m, ok := blk.(PrimitiveBlock); ok {
+		m.pure = true
+		return m
+	}
+	return blk
+}
+
+// Marks a primitive block as nondeterministic, so recordings keep its outputs and replays reuse them.
+// Other blocks are returned unchanged, they may implement Nondeterminer themselves.
+func Nondeterministic(blk FunctionBlock) FunctionBlock {
+	if m, ok := blk.(PrimitiveBlock); ok {
+		m.random = true
+		return m
+	}
+	return blk
+}
+
 // Returns copies of all parameters in FunctionBlock
 func (m PrimitiveBlock) GetParams() (inputs ParamTypes, outputs ParamTypes) {
 	return m.inputs.Copy(), m.outputs.Copy()
@@ -50,7 +91,16 @@ func (m PrimitiveBlock) Run(inputs ParamValues,
 	stop chan bool,
 	err chan *FlowError,
 	id InstanceID) {
+	m.run(m.env.observer(nil), inputs, outputs, stop, err, id)
+}
+
+// Runs the function, letting obs know when it starts and finishes unless it is nil.
+func (m PrimitiveBlock) run(obs Observer, inputs ParamValues, outputs chan ParamValues, stop chan bool, err chan *FlowError, id InstanceID) {
 	ADDR := Address{m.GetName(), id}
+	if obs != nil {
+		obs.OnNodeStart(ADDR, inputs)
+	}
+	start := time.Now()
 
 	// Check types to ensure inputs are the type defined in input parameters
 	//chk_errs := CheckTypes(inputs, m.inputs)
@@ -64,19 +114,30 @@ func (m PrimitiveBlock) Run(inputs ParamValues,
 	f_err := make(chan *Error)
 	f_out := make(chan ParamValues)
 	f_stop := make(chan bool)
-	go m.fn(inputs, f_out, f_stop, f_err)
+	if m.random_fn != nil {
+		go m.random_fn(m.env.random(), inputs, f_out, f_stop, f_err)
+	} else {
+		go m.fn(inputs, f_out, f_stop, f_err)
+	}
 
 	// Wait for a stop or an output
 	for {
 		select {
 		case f_return := <-f_out: // If an output is returned
+			if obs != nil {
+				obs.OnNodeFinish(ADDR, inputs, f_return, time.Since(start))
+			}
 			outputs <- f_return // Return the data
 			return              // And stop the function
 		case <-stop: // If commanded to stop externally
 			f_stop <- true // Pass it on to subfunction
 			return         // And stop immediately
 		case temp_err := <-f_err: // If there is an error, save it
-			err <- &FlowError{temp_err, ADDR} // Pass it up the chain
+			flow_err := &FlowError{temp_err, ADDR}
+			if f, ok := obs.(FailureObserver); ok {
+				f.OnNodeFail(ADDR, inputs, flow_err, time.Since(start))
+			}
+			m.env.raise(err, flow_err) // Pass it up the chain
 			f_stop <- true
 			return // And stop the function
 		}
@@ -97,6 +158,15 @@ func BlockRun(blk FunctionBlock, f_in ParamValues, id InstanceID) (f_out chan Pa
 	return
 }
 
+// Like BlockRun, letting w observe the run.
+func blockRun(blk FunctionBlock, w Observer, f_in ParamValues, id InstanceID) (f_out chan ParamValues, f_stop chan bool, f_err chan *FlowError) {
+	f_out = make(chan ParamValues)
+	f_stop = make(chan bool)
+	f_err = make(chan *FlowError)
+	go runWatched(blk, w, f_in, f_out, f_stop, f_err, id)
+	return
+}
+
 // A Timeout block that can pass to the stop channel
 func Timeout(stop chan bool, sleeptime int) {
 	time.Sleep(time.Duration(sleeptime))
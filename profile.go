@@ -0,0 +1,341 @@
+package flow
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Time spent by every run of a node, summed over loop iterations and nested graphs.
+type NodeProfile struct {
+	Addr     Address
+	Runs     int
+	Compute  time.Duration // Running the block
+	Wait     time.Duration // From the first input of a run arriving to the last
+	Overhead time.Duration // From the last input arriving to the block starting
+}
+
+// Time spent by every iteration of a loop.
+type LoopProfile struct {
+	Addr       Address
+	Iterations int
+	Total      time.Duration
+}
+
+// One node run on the critical path.
+type PathStep struct {
+	Addr    Address
+	Compute time.Duration // Running the block
+	Delay   time.Duration // From the end of the step before to the block starting
+}
+
+// An Observer which measures where the time of the graphs or loops it is added to goes.
+// Inputs arriving at once for nodes at the same address in different graphs are counted together.
+type Profiler struct {
+	BaseObserver
+	lock     sync.Mutex
+	nodes    map[Address]*NodeProfile
+	loops    map[Address]*LoopProfile
+	arriving map[Address]*arrival // Inputs arrived for the next run of each node
+	running  map[uintptr]*arrival // Inputs of each node run in progress, see runKey
+	last     map[Address]*execution
+	latest   *execution // The run which ended last
+}
+
+// Identifies a node run by its inputs, as OnNodeStart and OnNodeFinish receive the same map for it.
+// Tells apart runs of the same address in progress at once.
+func runKey(ins ParamValues) uintptr {
+	return reflect.ValueOf(ins).Pointer()
+}
+
+// When the inputs of a node run arrived, and which run passed the last one.
+type arrival struct {
+	first  time.Time
+	last   time.Time
+	parent *execution
+	start  time.Time
+}
+
+// A node run, linked to the run which made it ready.
+type execution struct {
+	addr   Address
+	start  time.Time
+	end    time.Time
+	parent *execution
+}
+
+func NewProfiler() *Profiler {
+	p := &Profiler{}
+	p.Reset()
+	return p
+}
+
+// Discards every measurement.
+func (p *Profiler) Reset() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.nodes = make(map[Address]*NodeProfile)
+	p.loops = make(map[Address]*LoopProfile)
+	p.arriving = make(map[Address]*arrival)
+	p.running = make(map[uintptr]*arrival)
+	p.last = make(map[Address]*execution)
+	p.latest = nil
+}
+
+func (p *Profiler) OnValuePassed(src, dst ParamAddress, val interface{}) {
+	now := time.Now()
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	a, exists := p.arriving[dst.Addr]
+	if !exists {
+		a = &arrival{first: now}
+		p.arriving[dst.Addr] = a
+	}
+	a.last = now
+	a.parent = p.last[src.Addr]
+}
+
+func (p *Profiler) OnNodeStart(addr Address, ins ParamValues) {
+	now := time.Now()
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	a, exists := p.arriving[addr]
+	if !exists {
+		a = &arrival{first: now, last: now} // A node without inputs
+	}
+	delete(p.arriving, addr)
+	a.start = now
+	p.running[runKey(ins)] = a
+}
+
+func (p *Profiler) OnNodeFinish(addr Address, ins, outs ParamValues, d time.Duration) {
+	end := time.Now()
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	a, exists := p.running[runKey(ins)]
+	if !exists {
+		a = &arrival{first: end.Add(-d), last: end.Add(-d), start: end.Add(-d)}
+	}
+	delete(p.running, runKey(ins))
+	delete(p.arriving, addr) // Outputs of a graph share its address, they are not inputs of its next run
+
+	np, exists := p.nodes[addr]
+	if !exists {
+		np = &NodeProfile{Addr: addr}
+		p.nodes[addr] = np
+	}
+	np.Runs += 1
+	np.Compute += d
+	np.Wait += a.last.Sub(a.first)
+	np.Overhead += a.start.Sub(a.last)
+
+	ex := &execution{addr, end.Add(-d), end, a.parent}
+	p.last[addr] = ex
+	if p.latest == nil || ex.end.After(p.latest.end) {
+		p.latest = ex
+	}
+}
+
+// An error ends the run it is raised in, so nodes still waiting on inputs or running will not finish.
+// Their entries are dropped, so that later runs at the same addresses do not count them.
+// Nodes of other runs observed at the same time are then profiled without their wait.
+func (p *Profiler) OnError(err *FlowError) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.arriving = make(map[Address]*arrival)
+	p.running = make(map[uintptr]*arrival)
+}
+
+func (p *Profiler) OnLoopIteration(addr Address, i int, ins, outs ParamValues, d time.Duration) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	lp, exists := p.loops[addr]
+	if !exists {
+		lp = &LoopProfile{Addr: addr}
+		p.loops[addr] = lp
+	}
+	lp.Iterations += 1
+	lp.Total += d
+}
+
+// Returns the profile of every node, the most computing first.
+func (p *Profiler) Nodes() []NodeProfile {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	out := make([]NodeProfile, 0, len(p.nodes))
+	for _, np := range p.nodes {
+		out = append(out, *np)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Compute != out[j].Compute {
+			return out[i].Compute > out[j].Compute
+		}
+		return addrLess(out[i].Addr, out[j].Addr)
+	})
+	return out
+}
+
+// Returns the profile of every loop, the longest first.
+func (p *Profiler) Loops() []LoopProfile {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	out := make([]LoopProfile, 0, len(p.loops))
+	for _, lp := range p.loops {
+		out = append(out, *lp)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Total != out[j].Total {
+			return out[i].Total > out[j].Total
+		}
+		return addrLess(out[i].Addr, out[j].Addr)
+	})
+	return out
+}
+
+// Returns the chain of node runs which ended last, following at each step
+// the run which passed the last input. The first step comes first.
+func (p *Profiler) CriticalPath() []PathStep {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	path := make([]PathStep, 0)
+	for ex := p.latest; ex != nil; ex = ex.parent {
+		step := PathStep{Addr: ex.addr, Compute: ex.end.Sub(ex.start)}
+		if ex.parent != nil {
+			step.Delay = ex.start.Sub(ex.parent.end)
+		}
+		path = append([]PathStep{step}, path...)
+	}
+	return path
+}
+
+// Writes the profile as a table of nodes and loops followed by the critical path.
+func (p *Profiler) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NODE\tRUNS\tCOMPUTE\tWAIT\tOVERHEAD")
+	for _, np := range p.Nodes() {
+		fmt.Fprintf(tw, "%s:%d\t%d\t%v\t%v\t%v\n", np.Addr.Name, np.Addr.ID, np.Runs, np.Compute, np.Wait, np.Overhead)
+	}
+	if loops := p.Loops(); len(loops) > 0 {
+		fmt.Fprintln(tw, "\nLOOP\tITERATIONS\tTOTAL\t\t")
+		for _, lp := range loops {
+			fmt.Fprintf(tw, "%s:%d\t%d\t%v\t\t\n", lp.Addr.Name, lp.Addr.ID, lp.Iterations, lp.Total)
+		}
+	}
+	fmt.Fprintln(tw, "\nCRITICAL PATH\t\tCOMPUTE\tDELAY\t")
+	for _, step := range p.CriticalPath() {
+		fmt.Fprintf(tw, "%s:%d\t\t%v\t%v\t\n", step.Addr.Name, step.Addr.ID, step.Compute, step.Delay)
+	}
+	return tw.Flush()
+}
+
+// Writes the profile in the gzipped protocol buffer format read by go tool pprof.
+// Every node is a function with samples of its runs, compute, wait and overhead nanoseconds.
+// Loops are functions whose compute is the time of all their iterations.
+func (p *Profiler) WritePprof(w io.Writer) error {
+	var prof protoBuffer
+	strs := map[string]int64{"": 0}
+	table := []string{""}
+	str := func(s string) int64 {
+		i, exists := strs[s]
+		if !exists {
+			i = int64(len(table))
+			strs[s] = i
+			table = append(table, s)
+		}
+		return i
+	}
+
+	// Sample types
+	for _, t := range [][2]string{{"runs", "count"}, {"compute", "nanoseconds"}, {"wait", "nanoseconds"}, {"overhead", "nanoseconds"}} {
+		var vt protoBuffer
+		vt.varint(1, uint64(str(t[0])))
+		vt.varint(2, uint64(str(t[1])))
+		prof.bytes(1, vt.Bytes())
+	}
+
+	// One function, location and sample for each node and loop
+	add := func(id uint64, addr Address, values ...int64) {
+		var sample protoBuffer
+		sample.varint(1, id)
+		for _, v := range values {
+			sample.varint(2, uint64(v))
+		}
+		prof.bytes(2, sample.Bytes())
+
+		var line protoBuffer
+		line.varint(1, id)
+		var loc protoBuffer
+		loc.varint(1, id)
+		loc.bytes(4, line.Bytes())
+		prof.bytes(4, loc.Bytes())
+
+		var fn protoBuffer
+		fn.varint(1, id)
+		fn.varint(2, uint64(str(fmt.Sprintf("%s:%d", addr.Name, addr.ID))))
+		fn.varint(3, uint64(str(addr.Name)))
+		prof.bytes(5, fn.Bytes())
+	}
+	id := uint64(0)
+	for _, np := range p.Nodes() {
+		id += 1
+		add(id, np.Addr, int64(np.Runs), int64(np.Compute), int64(np.Wait), int64(np.Overhead))
+	}
+	for _, lp := range p.Loops() {
+		id += 1
+		add(id, lp.Addr, int64(lp.Iterations), int64(lp.Total), 0, 0)
+	}
+
+	for _, s := range table {
+		prof.bytes(6, []byte(s))
+	}
+	var vt protoBuffer
+	vt.varint(1, uint64(strs["compute"]))
+	vt.varint(2, uint64(strs["nanoseconds"]))
+	prof.bytes(11, vt.Bytes())
+	prof.varint(12, 1)
+	prof.varint(14, uint64(strs["compute"])) // Default sample type
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(prof.Bytes()); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Returns true if a sorts before b.
+func addrLess(a, b Address) bool {
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	return a.ID < b.ID
+}
+
+// Writes protocol buffer fields.
+type protoBuffer struct {
+	bytes.Buffer
+}
+
+func (b *protoBuffer) uvarint(x uint64) {
+	for x >= 0x80 {
+		b.WriteByte(byte(x) | 0x80)
+		x >>= 7
+	}
+	b.WriteByte(byte(x))
+}
+
+func (b *protoBuffer) varint(field int, x uint64) {
+	b.uvarint(uint64(field) << 3)
+	b.uvarint(x)
+}
+
+func (b *protoBuffer) bytes(field int, data []byte) {
+	b.uvarint(uint64(field)<<3 | 2)
+	b.uvarint(uint64(len(data)))
+	b.Write(data)
+}
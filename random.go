@@ -0,0 +1,54 @@
+package flow
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// A DataStream which draws its random numbers from rng.
+type RandomStream func(rng *rand.Rand,
+	inputs ParamValues,
+	outputs chan ParamValues,
+	stop chan bool,
+	err chan *Error)
+
+// Initializes a nondeterministic primitive block whose function draws from the random
+// numbers of the graph or loop it runs in. Graphs set deterministic share one seeded source.
+func NewRandomPrimitive(name string, function RandomStream, inputs ParamTypes, outputs ParamTypes) FunctionBlock {
+	m := NewPrimitive(name, nil, inputs, outputs).(PrimitiveBlock)
+	m.random_fn = function
+	m.random = true
+	return m
+}
+
+// Used by blocks which are not in a deterministic graph.
+var globalRand = newRand(time.Now().UnixNano())
+
+// Creates a source of random numbers which can be shared by several goroutines.
+func newRand(seed int64) *rand.Rand {
+	return rand.New(&lockedSource{src: rand.NewSource(seed).(rand.Source64)})
+}
+
+type lockedSource struct {
+	lock sync.Mutex
+	src  rand.Source64
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.src.Uint64()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.src.Seed(seed)
+}
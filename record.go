@@ -0,0 +1,261 @@
+package flow
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+)
+
+func init() {
+	gob.Register(NoValue)
+}
+
+// NoValue is written to recordings as an empty value.
+func (v noValue) GobEncode() ([]byte, error) { return []byte{}, nil }
+func (v *noValue) GobDecode([]byte) error    { return nil }
+
+// A value passed on an edge during a recorded run.
+type EdgeRecord struct {
+	Path  []Address // Identifies the blocks the edge is nested in, from the outermost
+	Src   ParamAddress
+	Dst   ParamAddress
+	Value interface{}
+}
+
+// The outputs a nondeterministic block returned during a recorded run.
+type BlockRecord struct {
+	Path    []Address // The blocks the node is nested in, as in EdgeRecord
+	Addr    Address
+	Outputs ParamValues
+}
+
+// Everything needed to replay a run of a graph, loop or case structure.
+// Values of custom types must be registered with encoding/gob before writing or reading it.
+type Recording struct {
+	Inputs  ParamValues
+	Edges   []EdgeRecord  // In the order values were passed
+	Blocks  []BlockRecord // In the order the blocks returned
+	Outputs ParamValues   // Nil if the run returned an error
+	Err     *FlowError
+}
+
+// Runs blk once with inputs, recording every value passed on its edges,
+// and on the edges of every block nested inside it, and the outputs of nondeterministic blocks.
+func Record(blk FunctionBlock, inputs ParamValues, id InstanceID) (*Recording, *Error) {
+	s, ok := blk.(settled)
+	if !ok {
+		return nil, &Error{TYPE_ERROR, "Only graphs, loops and case structures can be recorded."}
+	}
+	t := &tape{rec: &Recording{Inputs: inputs.Copy()}}
+	s.settings().setTape(t)
+	defer s.settings().setTape(nil)
+
+	out, _, err := BlockRun(blk, inputs, id)
+	var vals ParamValues
+	var flow_err *FlowError
+	select {
+	case vals = <-out:
+	case flow_err = <-err:
+	}
+
+	// Blocks still running after the end are left out
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.closed = true
+	rec := *t.rec
+	rec.Outputs, rec.Err = vals, flow_err
+	return &rec, nil
+}
+
+// Writes the recording to w.
+func (r *Recording) Write(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(r)
+}
+
+// Reads a recording written by Recording.Write.
+func ReadRecording(rd io.Reader) (*Recording, error) {
+	rec := &Recording{}
+	if err := gob.NewDecoder(rd).Decode(rec); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// The first value passed during a replay which differs from the recording.
+type Divergence struct {
+	Src      ParamAddress
+	Dst      ParamAddress
+	Index    int         // How many values the edge passed before this one
+	Expected interface{} // Nil if the recording has no value here
+	Got      interface{}
+}
+
+func (d Divergence) Error() string {
+	return fmt.Sprintf("Replay diverged on %s:%d[%s] -> %s:%d[%s] at value %d: expected %v, got %v.",
+		d.Src.Addr.Name, d.Src.Addr.ID, d.Src.Name, d.Dst.Addr.Name, d.Dst.Addr.ID, d.Dst.Name,
+		d.Index, d.Expected, d.Got)
+}
+
+// Runs blk again with the inputs of rec. Nondeterministic blocks return their recorded
+// outputs instead of running, and every value passed is checked against the recording.
+// Stops at the first divergence and returns it, otherwise returns the outputs or error of the run.
+func Replay(blk FunctionBlock, rec *Recording, id InstanceID) (ParamValues, *Divergence, *FlowError) {
+	s, ok := blk.(settled)
+	if !ok {
+		return nil, nil, NewFlowError(TYPE_ERROR, "Only graphs, loops and case structures can be replayed.", Address{blk.GetName(), id})
+	}
+	t := newReplay(rec)
+	s.settings().setTape(t)
+	defer s.settings().setTape(nil)
+
+	out, stop, err := BlockRun(blk, rec.Inputs.Copy(), id)
+	var vals ParamValues
+	var flow_err *FlowError
+	select {
+	case vals = <-out:
+	case flow_err = <-err:
+	case <-t.halt:
+		// Stop the run, unless it is already returning
+		select {
+		case stop <- true:
+		case <-out:
+		case <-err:
+		}
+	}
+
+	// A divergence is returned even if the run went on to return
+	select {
+	case <-t.halt:
+		return nil, t.diverged, nil
+	default:
+		return vals, nil, flow_err
+	}
+}
+
+// Identifies the graph, loop or case structure a node or edge is in by the blocks it is nested in.
+func pathKey(path []Address) string {
+	key := ""
+	for _, addr := range path {
+		key += fmt.Sprintf("%s:%d/", addr.Name, addr.ID)
+	}
+	return key
+}
+
+// Identifies a nondeterministic node.
+type blockKey struct {
+	path string
+	addr Address
+}
+
+// Identifies an edge, leaving out what recordings do not keep.
+type edgeKey struct {
+	path     string
+	src_name string
+	src      Address
+	dst_name string
+	dst      Address
+}
+
+func keyOf(path []Address, src, dst ParamAddress) edgeKey {
+	return edgeKey{pathKey(path), src.Name, src.Addr, dst.Name, dst.Addr}
+}
+
+// Records a run, or checks a replay against a recording.
+type tape struct {
+	lock      sync.Mutex
+	rec       *Recording
+	replaying bool
+	closed    bool // True once the recorded run has ended
+
+	expected map[edgeKey][]interface{}  // Recorded values of each edge
+	passed   map[edgeKey]int            // Number of values each edge passed during the replay
+	outputs  map[blockKey][]ParamValues // Recorded outputs of each nondeterministic block
+	returned map[blockKey]int           // Number of recorded outputs each block used
+	diverged *Divergence
+	halt     chan struct{} // Closed at the first divergence
+}
+
+func newReplay(rec *Recording) *tape {
+	t := &tape{rec: rec, replaying: true, halt: make(chan struct{}),
+		expected: make(map[edgeKey][]interface{}), passed: make(map[edgeKey]int),
+		outputs: make(map[blockKey][]ParamValues), returned: make(map[blockKey]int)}
+	for _, e := range rec.Edges {
+		k := keyOf(e.Path, e.Src, e.Dst)
+		t.expected[k] = append(t.expected[k], e.Value)
+	}
+	for _, b := range rec.Blocks {
+		k := blockKey{pathKey(b.Path), b.Addr}
+		t.outputs[k] = append(t.outputs[k], b.Outputs)
+	}
+	return t
+}
+
+// Passes the values passed on the edges of one graph to a tape, along with the path to the graph.
+type tapeAt struct {
+	BaseObserver
+	t    *tape
+	path []Address
+}
+
+func (ta tapeAt) OnValuePassed(src, dst ParamAddress, val interface{}) {
+	ta.t.onValue(ta.path, src, dst, val)
+}
+
+// Records a value passed on an edge, or checks it against the recording.
+func (t *tape) onValue(path []Address, src, dst ParamAddress, val interface{}) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	switch {
+	case t.closed:
+		return
+	case !t.replaying:
+		t.rec.Edges = append(t.rec.Edges, EdgeRecord{path, src, dst, val})
+		return
+	}
+
+	// Compare with the value the edge passed at the same point of the recording
+	k := keyOf(path, src, dst)
+	i := t.passed[k]
+	t.passed[k] += 1
+	var expected interface{}
+	if i < len(t.expected[k]) {
+		expected = t.expected[k][i]
+		if reflect.DeepEqual(expected, val) {
+			return
+		}
+	}
+	if t.diverged == nil {
+		t.diverged = &Divergence{src, dst, i, expected, val}
+		close(t.halt)
+	}
+}
+
+// Keeps the outputs of a nondeterministic block while recording.
+func (t *tape) record(path []Address, addr Address, outs ParamValues) {
+	if t == nil || t.replaying {
+		return
+	}
+	t.lock.Lock()
+	if !t.closed {
+		t.rec.Blocks = append(t.rec.Blocks, BlockRecord{path, addr, outs.Copy()})
+	}
+	t.lock.Unlock()
+}
+
+// Returns the next recorded outputs of a nondeterministic block while replaying.
+func (t *tape) replay(path []Address, addr Address) (ParamValues, bool) {
+	if t == nil || !t.replaying {
+		return nil, false
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	k := blockKey{pathKey(path), addr}
+	i := t.returned[k]
+	if i >= len(t.outputs[k]) {
+		return nil, false
+	}
+	t.returned[k] += 1
+	return t.outputs[k][i].Copy(), true
+}
@@ -0,0 +1,120 @@
+package flow
+
+import (
+	"sync"
+)
+
+// Starts the nodes of a graph once their inputs are ready.
+type Scheduler interface {
+	Go(task func())           // Runs task, now or later
+	Pending() <-chan struct{} // Receives when tasks are waiting to run, nil if they never wait
+	Help()                    // Runs waiting tasks on the calling goroutine
+}
+
+// The default scheduler, which starts every node on its own goroutine.
+type GoroutineScheduler struct{}
+
+func (s GoroutineScheduler) Go(task func())           { go task() }
+func (s GoroutineScheduler) Pending() <-chan struct{} { return nil }
+func (s GoroutineScheduler) Help()                    {}
+
+// A scheduler which runs at most a fixed number of nodes at once.
+// Tasks wait in order in a queue until a worker is free.
+// Graphs waiting for their outputs help run queued tasks,
+// so nested graphs sharing the pool can not starve it.
+type WorkerPool struct {
+	lock    sync.Mutex
+	queue   []func()
+	workers int // Number of workers allowed
+	running int // Number of workers started
+	pending chan struct{}
+	closed  bool
+}
+
+// Creates a pool of at most workers goroutines. Workers are started as tasks arrive.
+func NewWorkerPool(workers int) *WorkerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	return &WorkerPool{workers: workers, pending: make(chan struct{}, 1)}
+}
+
+func (p *WorkerPool) Go(task func()) {
+	p.lock.Lock()
+	p.queue = append(p.queue, task)
+	if p.running < p.workers && !p.closed {
+		p.running += 1
+		go p.work()
+	}
+	p.lock.Unlock()
+	p.signal()
+}
+
+func (p *WorkerPool) Pending() <-chan struct{} { return p.pending }
+
+// Creates a scheduler which never starts goroutines of its own. Nodes run one at a time,
+// in the order they became ready, on the goroutines of the graphs waiting for them.
+func NewSerialScheduler() *WorkerPool {
+	return &WorkerPool{workers: 0, pending: make(chan struct{}, 1)}
+}
+
+// Runs the next waiting task, if any.
+func (p *WorkerPool) Help() {
+	if task := p.next(); task != nil {
+		task()
+	}
+	p.signal()
+}
+
+// Stops the workers once the queue is empty. Tasks given afterwards only run when helped.
+func (p *WorkerPool) Close() {
+	p.lock.Lock()
+	p.closed = true
+	p.lock.Unlock()
+	p.signal()
+}
+
+// Runs tasks until the queue is empty or the pool is closed.
+func (p *WorkerPool) work() {
+	for {
+		task := p.next()
+		if task == nil {
+			p.lock.Lock()
+			// Check again, a task may have been queued while no worker was counted as idle
+			if len(p.queue) > 0 && !p.closed {
+				p.lock.Unlock()
+				continue
+			}
+			p.running -= 1
+			p.lock.Unlock()
+			return
+		}
+		task()
+	}
+}
+
+// Removes the first task from the queue, or returns nil if it is empty.
+func (p *WorkerPool) next() func() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if len(p.queue) == 0 {
+		return nil
+	}
+	task := p.queue[0]
+	p.queue[0] = nil
+	p.queue = p.queue[1:]
+	return task
+}
+
+// Lets helpers know tasks are waiting.
+func (p *WorkerPool) signal() {
+	p.lock.Lock()
+	waiting := len(p.queue) > 0
+	p.lock.Unlock()
+	if waiting {
+		select {
+		case p.pending <- struct{}{}:
+		default:
+		}
+	}
+}
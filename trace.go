@@ -0,0 +1,207 @@
+package flow
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Kinds of traced events:
+const (
+	NODE_EVENT      = "node"      // One run of a node in a graph
+	ITERATION_EVENT = "iteration" // One iteration of a loop
+	ERROR_EVENT     = "error"     // An error raised by a block, which starts and ends at once where it was raised
+)
+
+// A span of execution recorded by a Tracer.
+type TraceEvent struct {
+	Kind      string
+	Addr      Address
+	Iteration int // Index of the loop iteration, -1 for nodes
+	Start     time.Time
+	End       time.Time
+	Inputs    ParamValues
+	Outputs   ParamValues // Nil if the block did not return
+	Err       *FlowError  // Nil unless the block returned an error
+}
+
+// An Observer which records a TraceEvent for every node run, loop iteration and error
+// of the graphs or loops it is added to with AddObserver or SetTracer.
+type Tracer struct {
+	BaseObserver
+	lock   sync.Mutex
+	events []TraceEvent
+	redact func(param string, val interface{}) interface{}
+}
+
+func NewTracer() *Tracer {
+	return &Tracer{}
+}
+
+// Replaces every recorded input and output value by what redact returns for it.
+// Use RedactAll to record no values at all.
+func (t *Tracer) SetRedact(redact func(param string, val interface{}) interface{}) {
+	t.lock.Lock()
+	t.redact = redact
+	t.lock.Unlock()
+}
+
+// Hides every value.
+func RedactAll(param string, val interface{}) interface{} {
+	return "<redacted>"
+}
+
+// Returns a copy of the events recorded so far, in the order they ended.
+func (t *Tracer) Events() []TraceEvent {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return append([]TraceEvent{}, t.events...)
+}
+
+// Discards every recorded event.
+func (t *Tracer) Reset() {
+	t.lock.Lock()
+	t.events = nil
+	t.lock.Unlock()
+}
+
+func (t *Tracer) OnNodeFinish(addr Address, ins, outs ParamValues, d time.Duration) {
+	end := time.Now()
+	t.record(TraceEvent{NODE_EVENT, addr, -1, end.Add(-d), end, ins, outs, nil})
+}
+
+func (t *Tracer) OnLoopIteration(addr Address, i int, ins, outs ParamValues, d time.Duration) {
+	end := time.Now()
+	t.record(TraceEvent{ITERATION_EVENT, addr, i, end.Add(-d), end, ins, outs, nil})
+}
+
+func (t *Tracer) OnNodeFail(addr Address, ins ParamValues, err *FlowError, d time.Duration) {
+	end := time.Now()
+	t.record(TraceEvent{NODE_EVENT, addr, -1, end.Add(-d), end, ins, nil, err})
+}
+
+func (t *Tracer) OnLoopIterationFail(addr Address, i int, ins ParamValues, err *FlowError, d time.Duration) {
+	end := time.Now()
+	t.record(TraceEvent{ITERATION_EVENT, addr, i, end.Add(-d), end, ins, nil, err})
+}
+
+func (t *Tracer) OnError(err *FlowError) {
+	now := time.Now()
+	t.record(TraceEvent{ERROR_EVENT, err.Addr, -1, now, now, nil, nil, err})
+}
+
+// Records an event, copying its values so the block can not change them afterwards.
+func (t *Tracer) record(ev TraceEvent) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	ev.Inputs = t.values(ev.Inputs)
+	ev.Outputs = t.values(ev.Outputs)
+	t.events = append(t.events, ev)
+}
+
+// Copies vals, redacting them if needed. Must hold lock.
+func (t *Tracer) values(vals ParamValues) ParamValues {
+	if vals == nil {
+		return nil
+	}
+	out := make(ParamValues, len(vals))
+	for name, val := range vals {
+		if t.redact != nil {
+			val = t.redact(name, val)
+		}
+		out[name] = val
+	}
+	return out
+}
+
+// Writes the recorded events in the Chrome trace event format,
+// which can be opened in chrome://tracing or Perfetto.
+// Events which overlap in time are placed on separate threads.
+func (t *Tracer) WriteChrome(w io.Writer) error {
+	events := t.Events()
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Start.Before(events[j].Start)
+	})
+
+	// Times are relative to the first event
+	var origin time.Time
+	if len(events) > 0 {
+		origin = events[0].Start
+	}
+
+	// Place each event on the first thread which is free when it starts
+	lanes := make([]time.Time, 0) // When each thread is free again
+	out := make([]chromeEvent, 0, len(events))
+	for _, ev := range events {
+		ts := float64(ev.Start.Sub(origin).Nanoseconds()) / 1000
+		name := fmt.Sprintf("%s:%d", ev.Addr.Name, ev.Addr.ID)
+		if ev.Kind == ERROR_EVENT {
+			args := map[string]interface{}{"error": ev.Err.Info}
+			out = append(out, chromeEvent{Name: name, Cat: ev.Kind, Ph: "i", S: "g", Ts: ts, Pid: 1, Args: args})
+			continue
+		}
+
+		tid := 0
+		for tid < len(lanes) && lanes[tid].After(ev.Start) {
+			tid += 1
+		}
+		if tid == len(lanes) {
+			lanes = append(lanes, ev.End)
+		} else {
+			lanes[tid] = ev.End
+		}
+
+		args := map[string]interface{}{"inputs": jsonValues(ev.Inputs)}
+		if ev.Kind == ITERATION_EVENT {
+			name = fmt.Sprintf("%s[%d]", name, ev.Iteration)
+			args["iteration"] = ev.Iteration
+		}
+		if ev.Outputs != nil {
+			args["outputs"] = jsonValues(ev.Outputs)
+		}
+		if ev.Err != nil {
+			args["error"] = ev.Err.Info
+		}
+		out = append(out, chromeEvent{
+			Name: name,
+			Cat:  ev.Kind,
+			Ph:   "X",
+			Ts:   ts,
+			Dur:  float64(ev.End.Sub(ev.Start).Nanoseconds()) / 1000,
+			Pid:  1,
+			Tid:  tid,
+			Args: args})
+	}
+	return json.NewEncoder(w).Encode(chromeTrace{out})
+}
+
+type chromeTrace struct {
+	TraceEvents []chromeEvent `json:"traceEvents"`
+}
+
+type chromeEvent struct {
+	Name string                 `json:"name"`
+	Cat  string                 `json:"cat"`
+	Ph   string                 `json:"ph"`
+	S    string                 `json:"s,omitempty"` // Scope of instant events
+	Ts   float64                `json:"ts"`          // Microseconds
+	Dur  float64                `json:"dur"`         // Microseconds
+	Pid  int                    `json:"pid"`
+	Tid  int                    `json:"tid"`
+	Args map[string]interface{} `json:"args"`
+}
+
+// Converts values which can not be written as JSON to strings.
+func jsonValues(vals ParamValues) map[string]interface{} {
+	out := make(map[string]interface{}, len(vals))
+	for name, val := range vals {
+		if _, err := json.Marshal(val); err != nil || val == NoValue {
+			val = fmt.Sprint(val)
+		}
+		out[name] = val
+	}
+	return out
+}